@@ -134,6 +134,9 @@ func (a *Agent) Run(ctx context.Context) error {
 
 	wg.Wait()
 
+	log.Printf("D! [agent] Closing processors")
+	a.closeProcessors()
+
 	log.Printf("D! [agent] Closing outputs")
 	a.closeOutputs()
 
@@ -307,9 +310,11 @@ func (a *Agent) gatherOnInterval(
 			return
 		}
 
-		err = a.gatherOnce(acc, input, interval)
-		if err != nil {
-			acc.AddError(err)
+		if !input.IsPaused() {
+			err = a.gatherOnce(acc, input, interval)
+			if err != nil {
+				acc.AddError(err)
+			}
 		}
 
 		select {
@@ -685,6 +690,13 @@ func (a *Agent) closeOutputs() {
 	}
 }
 
+// closeProcessors releases the resources held by the processors.
+func (a *Agent) closeProcessors() {
+	for _, processor := range a.Config.Processors {
+		processor.Close()
+	}
+}
+
 // startServiceInputs starts all service inputs.
 func (a *Agent) startServiceInputs(
 	ctx context.Context,
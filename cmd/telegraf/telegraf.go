@@ -1,10 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	_ "net/http/pprof" // Comment this line to disable pprof endpoint.
@@ -41,9 +43,17 @@ var fTestWait = flag.Int("test-wait", 0, "wait up to this many seconds for servi
 var fConfig = flag.String("config", "", "configuration file to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
+var fConfigURLWatchInterval = flag.Duration("config-url-watch-interval", 0,
+	"how often to check a config loaded from a URL for changes, 0 disables the check")
+var fConfigKeyFile = flag.String("config-key-file", "",
+	"file containing the key of encrypted configuration values")
+var fEncrypt = flag.Bool("encrypt", false,
+	"encrypt the value read from stdin for use in the configuration")
 var fVersion = flag.Bool("version", false, "display the version and exit")
 var fSampleConfig = flag.Bool("sample-config", false,
 	"print out full sample configuration")
+var fSchema = flag.Bool("schema", false,
+	"print the configuration schema of the plugins as JSON")
 var fPidfile = flag.String("pidfile", "", "file to write our pid to")
 var fSectionFilters = flag.String("section-filter", "",
 	"filter the sections to print, separator is ':'. Valid values are 'agent', 'global_tags', 'outputs', 'processors', 'aggregators' and 'inputs'")
@@ -91,6 +101,9 @@ func reloadLoop(
 
 		ctx, cancel := context.WithCancel(context.Background())
 
+		// Receives when the remote config changed.
+		changed := make(chan struct{}, 1)
+
 		signals := make(chan os.Signal)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP,
 			syscall.SIGTERM, syscall.SIGINT)
@@ -103,12 +116,17 @@ func reloadLoop(
 					reload <- true
 				}
 				cancel()
+			case <-changed:
+				log.Printf("I! Reloading Telegraf config, remote config changed")
+				<-reload
+				reload <- true
+				cancel()
 			case <-stop:
 				cancel()
 			}
 		}()
 
-		err := runAgent(ctx, inputFilters, outputFilters)
+		err := runAgent(ctx, inputFilters, outputFilters, changed)
 		if err != nil && err != context.Canceled {
 			log.Fatalf("E! [telegraf] Error running agent: %v", err)
 		}
@@ -118,41 +136,16 @@ func reloadLoop(
 func runAgent(ctx context.Context,
 	inputFilters []string,
 	outputFilters []string,
+	changed chan<- struct{},
 ) error {
 	log.Printf("I! Starting Telegraf %s", version)
 
 	// If no other options are specified, load the config file and run.
-	c := config.NewConfig()
-	c.OutputFilters = outputFilters
-	c.InputFilters = inputFilters
-	err := c.LoadConfig(*fConfig)
+	c, err := loadConfig(inputFilters, outputFilters)
 	if err != nil {
 		return err
 	}
 
-	if *fConfigDirectory != "" {
-		err = c.LoadDirectory(*fConfigDirectory)
-		if err != nil {
-			return err
-		}
-	}
-	if !*fTest && len(c.Outputs) == 0 {
-		return errors.New("Error: no outputs found, did you provide a valid config file?")
-	}
-	if *fPlugins == "" && len(c.Inputs) == 0 {
-		return errors.New("Error: no inputs found, did you provide a valid config file?")
-	}
-
-	if int64(c.Agent.Interval.Duration) <= 0 {
-		return fmt.Errorf("Agent interval must be positive, found %s",
-			c.Agent.Interval.Duration)
-	}
-
-	if int64(c.Agent.FlushInterval.Duration) <= 0 {
-		return fmt.Errorf("Agent flush_interval must be positive; found %s",
-			c.Agent.Interval.Duration)
-	}
-
 	ag, err := agent.NewAgent(c)
 	if err != nil {
 		return err
@@ -200,9 +193,89 @@ func runAgent(ctx context.Context,
 		}
 	}
 
+	if *fConfigURLWatchInterval > 0 && c.HasRemoteConfig() {
+		go watchRemoteConfig(ctx, c, *fConfigURLWatchInterval, changed)
+	}
+
 	return ag.Run(ctx)
 }
 
+func loadConfig(inputFilters []string, outputFilters []string) (*config.Config, error) {
+	c := config.NewConfig()
+	c.OutputFilters = outputFilters
+	c.InputFilters = inputFilters
+	c.KeyFile = *fConfigKeyFile
+	err := c.LoadConfig(*fConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if *fConfigDirectory != "" {
+		err = c.LoadDirectory(*fConfigDirectory)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !*fTest && len(c.Outputs) == 0 {
+		return nil, errors.New("Error: no outputs found, did you provide a valid config file?")
+	}
+	if *fPlugins == "" && len(c.Inputs) == 0 {
+		return nil, errors.New("Error: no inputs found, did you provide a valid config file?")
+	}
+
+	if int64(c.Agent.Interval.Duration) <= 0 {
+		return nil, fmt.Errorf("Agent interval must be positive, found %s",
+			c.Agent.Interval.Duration)
+	}
+
+	if int64(c.Agent.FlushInterval.Duration) <= 0 {
+		return nil, fmt.Errorf("Agent flush_interval must be positive; found %s",
+			c.Agent.Interval.Duration)
+	}
+	return c, nil
+}
+
+// watchRemoteConfig checks the config loaded from URLs for changes, once it
+// changed and the new config loads without errors changed is signaled.
+func watchRemoteConfig(
+	ctx context.Context,
+	c *config.Config,
+	interval time.Duration,
+	changed chan<- struct{},
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ok, err := c.RemoteConfigChanged()
+		if err != nil {
+			log.Printf("W! Unable to check remote config: %v", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		// Keep running with the current config if the new one is invalid.
+		if _, err := loadConfig(c.InputFilters, c.OutputFilters); err != nil {
+			log.Printf("E! Not reloading changed remote config: %v", err)
+			continue
+		}
+
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+		return
+	}
+}
+
 func usageExit(rc int) {
 	fmt.Println(internal.Usage)
 	os.Exit(rc)
@@ -257,6 +330,33 @@ func formatFullVersion() string {
 	return strings.Join(parts, " ")
 }
 
+// encryptValue prints the value read from stdin encrypted with the key.
+func encryptValue(keyFile string) error {
+	if keyFile == "" {
+		keyFile = os.Getenv("TELEGRAF_CONFIG_KEY_FILE")
+	}
+	if keyFile == "" {
+		return errors.New("--encrypt requires --config-key-file or $TELEGRAF_CONFIG_KEY_FILE")
+	}
+	key, err := config.LoadKey(keyFile)
+	if err != nil {
+		return err
+	}
+
+	value, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	value = bytes.TrimRight(value, "\r\n")
+
+	encrypted, err := config.Encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	fmt.Println(encrypted)
+	return nil
+}
+
 func main() {
 	flag.Usage = func() { usageExit(0) }
 	flag.Parse()
@@ -361,6 +461,23 @@ func main() {
 			processorFilters,
 		)
 		return
+	case *fSchema:
+		err := config.PrintSchema(
+			sectionFilters,
+			inputFilters,
+			outputFilters,
+			aggregatorFilters,
+			processorFilters,
+		)
+		if err != nil {
+			log.Fatal("E! " + err.Error())
+		}
+		return
+	case *fEncrypt:
+		if err := encryptValue(*fConfigKeyFile); err != nil {
+			log.Fatal("E! " + err.Error())
+		}
+		return
 	case *fUsage != "":
 		err := config.PrintInputConfig(*fUsage)
 		err2 := config.PrintOutputConfig(*fUsage)
@@ -0,0 +1,31 @@
+package filter
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/expression"
+)
+
+// Expression is a condition on the name, tags and fields of a metric.
+type Expression struct {
+	condition *expression.Condition
+}
+
+// CompileExpression parses an expression for matching metrics, ie:
+//
+//   e, _ := CompileExpression(`fields.value < 0 or tags.status =~ "^err"`)
+//   e.Match(m) // true if the value field is negative or the status tag
+//              // starts with "err"
+//
+// See expression.CompileCondition for the syntax.
+func CompileExpression(expr string) (*Expression, error) {
+	c, err := expression.CompileCondition(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{condition: c}, nil
+}
+
+// Match returns true if the metric matches the expression.
+func (e *Expression) Match(m telegraf.Metric) bool {
+	return e.condition.Match(m)
+}
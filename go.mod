@@ -90,7 +90,7 @@ require (
 	github.com/miekg/dns v1.0.14
 	github.com/mitchellh/go-testing-interface v1.0.0 // indirect
 	github.com/multiplay/go-ts3 v1.0.0
-	github.com/naoina/go-stringutil v0.1.0 // indirect
+	github.com/naoina/go-stringutil v0.1.0
 	github.com/nats-io/nats-server/v2 v2.1.4
 	github.com/nats-io/nats.go v1.9.1
 	github.com/nsqio/go-nsq v1.0.7
@@ -2,6 +2,7 @@ package config
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -66,6 +67,14 @@ type Config struct {
 	Aggregators []*models.RunningAggregator
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors models.RunningProcessors
+
+	// KeyFile contains the key of encrypted values, by default the file
+	// named by $TELEGRAF_CONFIG_KEY_FILE is used.
+	KeyFile string
+	key     []byte
+
+	// Remote config files loaded, to check for changes.
+	remotes []*remoteConfig
 }
 
 func NewConfig() *Config {
@@ -131,11 +140,26 @@ type AgentConfig struct {
 	// not be less than 2 times MetricBatchSize.
 	MetricBufferLimit int
 
+	// MetricBatchBytes triggers a write to an output once the metrics added
+	// since the last write are larger than this size in line protocol, in
+	// addition to the flush interval and MetricBatchSize.  When set to 0
+	// the size is not checked.
+	MetricBatchBytes internal.Size `toml:"metric_batch_bytes"`
+
 	// FlushBufferWhenFull tells Telegraf to flush the metric buffer whenever
 	// it fills up, regardless of FlushInterval. Setting this option to true
 	// does _not_ deactivate FlushInterval.
 	FlushBufferWhenFull bool
 
+	// MetricBufferDirectory enables the disk buffer.  When set, metrics that
+	// do not fit into the memory buffer, or are unsent on shutdown, are
+	// stored in a subdirectory per output and sent once the output recovers.
+	MetricBufferDirectory string `toml:"metric_buffer_directory"`
+
+	// MetricBufferDiskLimit is the maximum size of the disk buffer of each
+	// output.  When set to 0 the disk buffer is not limited.
+	MetricBufferDiskLimit internal.Size `toml:"metric_buffer_disk_limit"`
+
 	// TODO(cam): Remove UTC and parameter, they are no longer
 	// valid for the agent config. Leaving them here for now for backwards-
 	// compatibility
@@ -261,11 +285,25 @@ var agentConfig = `
   ## This controls the size of writes that Telegraf sends to output plugins.
   metric_batch_size = 1000
 
+  ## Telegraf will also send a batch once the metrics received since the last
+  ## write are larger than metric_batch_bytes in line protocol, which keeps
+  ## writes small when metrics arrive in large bursts.  Disabled when 0.
+  # metric_batch_bytes = "1MB"
+
   ## Maximum number of unwritten metrics per output.  Increasing this value
   ## allows for longer periods of output downtime without dropping metrics at the
   ## cost of higher maximum memory usage.
   metric_buffer_limit = 10000
 
+  ## Directory used to store metrics that do not fit into the metric buffer,
+  ## or are unsent on shutdown, until the output recovers.  Each output uses
+  ## a subdirectory named after the output.  Disabled when not set.
+  # metric_buffer_directory = "/var/lib/telegraf/buffer"
+
+  ## Maximum size of the disk buffer per output, once reached new metrics are
+  ## dropped.  Set to 0 for no limit.
+  # metric_buffer_disk_limit = "1GB"
+
   ## Collection jitter is used to jitter the collection by a random amount.
   ## Each plugin will sleep for a random time within jitter before collecting.
   ## This can be used to avoid many plugins querying things like sysfs at the
@@ -682,12 +720,12 @@ func (c *Config) LoadConfig(path string) error {
 			return err
 		}
 	}
-	data, err := loadConfig(path)
+	data, err := c.loadConfig(path)
 	if err != nil {
 		return fmt.Errorf("Error loading %s, %s", path, err)
 	}
 
-	tbl, err := parseConfig(data)
+	tbl, err := c.parseConfig(data)
 	if err != nil {
 		return fmt.Errorf("Error parsing %s, %s", path, err)
 	}
@@ -834,7 +872,7 @@ func escapeEnv(value string) string {
 	return envVarEscaper.Replace(value)
 }
 
-func loadConfig(config string) ([]byte, error) {
+func (c *Config) loadConfig(config string) ([]byte, error) {
 	u, err := url.Parse(config)
 	if err != nil {
 		return nil, err
@@ -842,7 +880,7 @@ func loadConfig(config string) ([]byte, error) {
 
 	switch u.Scheme {
 	case "https", "http":
-		return fetchConfig(u)
+		return c.fetchConfig(u)
 	default:
 		// If it isn't a https scheme, try it as a file.
 	}
@@ -850,16 +888,11 @@ func loadConfig(config string) ([]byte, error) {
 
 }
 
-func fetchConfig(u *url.URL) ([]byte, error) {
-	req, err := http.NewRequest("GET", u.String(), nil)
+func (c *Config) fetchConfig(u *url.URL) ([]byte, error) {
+	req, err := newConfigRequest(u)
 	if err != nil {
 		return nil, err
 	}
-
-	if v, exists := os.LookupEnv("INFLUX_TOKEN"); exists {
-		req.Header.Add("Authorization", "Token "+v)
-	}
-	req.Header.Add("Accept", "application/toml")
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, err
@@ -870,13 +903,36 @@ func fetchConfig(u *url.URL) ([]byte, error) {
 	}
 
 	defer resp.Body.Close()
-	return ioutil.ReadAll(resp.Body)
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	c.remotes = append(c.remotes, &remoteConfig{
+		url:  u,
+		etag: resp.Header.Get("ETag"),
+		sum:  sha256.Sum256(body),
+	})
+	return body, nil
+}
+
+func newConfigRequest(u *url.URL) (*http.Request, error) {
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if v, exists := os.LookupEnv("INFLUX_TOKEN"); exists {
+		req.Header.Add("Authorization", "Token "+v)
+	}
+	req.Header.Add("Accept", "application/toml")
+	return req, nil
 }
 
 // parseConfig loads a TOML configuration from a provided path and
 // returns the AST produced from the TOML parser. When loading the file, it
-// will find environment variables and replace them.
-func parseConfig(contents []byte) (*ast.Table, error) {
+// will find environment variables and encrypted values and replace them.
+func (c *Config) parseConfig(contents []byte) (*ast.Table, error) {
 	contents = trimBOM(contents)
 
 	parameters := envVarRe.FindAllSubmatch(contents, -1)
@@ -901,6 +957,11 @@ func parseConfig(contents []byte) (*ast.Table, error) {
 		}
 	}
 
+	contents, err := c.decryptValues(contents)
+	if err != nil {
+		return nil, err
+	}
+
 	return toml.Parse(contents)
 }
 
@@ -976,6 +1037,25 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return err
 	}
 
+	if outputConfig.MetricBatchBytes == 0 {
+		outputConfig.MetricBatchBytes = c.Agent.MetricBatchBytes.Size
+	}
+
+	if c.Agent.MetricBufferDirectory != "" {
+		dirName := name
+		if outputConfig.Alias != "" {
+			dirName += "-" + outputConfig.Alias
+		}
+		dir := filepath.Join(c.Agent.MetricBufferDirectory, dirName)
+		for _, o := range c.Outputs {
+			if o.Config.BufferDirectory == dir {
+				return fmt.Errorf("outputs.%s: an alias is required to use the disk buffer with multiple instances of an output", name)
+			}
+		}
+		outputConfig.BufferDirectory = dir
+		outputConfig.BufferDiskLimit = c.Agent.MetricBufferDiskLimit.Size
+	}
+
 	ro := models.NewRunningOutput(name, output, outputConfig,
 		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
 	c.Outputs = append(c.Outputs, ro)
@@ -1127,6 +1207,14 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 		}
 	}
 
+	if node, ok := tbl.Fields["route"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				conf.Route = str.Value
+			}
+		}
+	}
+
 	conf.Tags = make(map[string]string)
 	if node, ok := tbl.Fields["tags"]; ok {
 		if subtbl, ok := node.(*ast.Table); ok {
@@ -1144,6 +1232,7 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "route")
 	delete(tbl.Fields, "tags")
 	var err error
 	conf.Filter, err = buildFilter(tbl)
@@ -1286,6 +1375,22 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["metricpass"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				f.MetricPass = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["metricdrop"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				f.MetricDrop = str.Value
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["tagexclude"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if ary, ok := kv.Value.(*ast.Array); ok {
@@ -1323,6 +1428,8 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 	delete(tbl.Fields, "tagpass")
 	delete(tbl.Fields, "tagexclude")
 	delete(tbl.Fields, "taginclude")
+	delete(tbl.Fields, "metricdrop")
+	delete(tbl.Fields, "metricpass")
 	return f, nil
 }
 
@@ -1344,6 +1451,34 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["error_limit"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				cp.ErrorLimit = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["error_cooldown"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.ErrorCooldown = dur
+			}
+		}
+	}
+	if cp.ErrorLimit > 0 && cp.ErrorCooldown == 0 {
+		cp.ErrorCooldown = 5 * time.Minute
+	}
+
 	if node, ok := tbl.Fields["name_prefix"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1376,6 +1511,26 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["alias_tag"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				cp.AliasTag, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["route"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.Route = str.Value
+			}
+		}
+	}
+
 	cp.Tags = make(map[string]string)
 	if node, ok := tbl.Fields["tags"]; ok {
 		if subtbl, ok := node.(*ast.Table); ok {
@@ -1389,7 +1544,11 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "alias_tag")
 	delete(tbl.Fields, "interval")
+	delete(tbl.Fields, "error_limit")
+	delete(tbl.Fields, "error_cooldown")
+	delete(tbl.Fields, "route")
 	delete(tbl.Fields, "tags")
 	var err error
 	cp.Filter, err = buildFilter(tbl)
@@ -1450,6 +1609,14 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["influx_on_parse_error"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.InfluxOnParseError = str.Value
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["tag_keys"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if ary, ok := kv.Value.(*ast.Array); ok {
@@ -1526,6 +1693,52 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["json_stream"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.JSONStream, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["jsonpath_query"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONPathQuery = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["jsonpath_fields"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			c.JSONPathFields = make(map[string]string, len(subtbl.Fields))
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						c.JSONPathFields[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["jsonpath_tags"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			c.JSONPathTags = make(map[string]string, len(subtbl.Fields))
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						c.JSONPathTags[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["data_type"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1810,11 +2023,82 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["table_column_names"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.TableColumnNames = append(c.TableColumnNames, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["table_skip_rows"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				c.TableSkipRows = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["table_tag_columns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.TableTagColumns = append(c.TableTagColumns, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["xml_query"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.XMLQuery = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["xml_fields"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			c.XMLFields = make(map[string]string, len(subtbl.Fields))
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						c.XMLFields[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["xml_tags"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			c.XMLTags = make(map[string]string, len(subtbl.Fields))
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						c.XMLTags[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+
 	c.MetricName = name
 
 	delete(tbl.Fields, "data_format")
 	delete(tbl.Fields, "separator")
 	delete(tbl.Fields, "templates")
+	delete(tbl.Fields, "influx_on_parse_error")
 	delete(tbl.Fields, "tag_keys")
 	delete(tbl.Fields, "json_name_key")
 	delete(tbl.Fields, "json_query")
@@ -1823,6 +2107,10 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 	delete(tbl.Fields, "json_time_key")
 	delete(tbl.Fields, "json_timezone")
 	delete(tbl.Fields, "json_strict")
+	delete(tbl.Fields, "json_stream")
+	delete(tbl.Fields, "jsonpath_query")
+	delete(tbl.Fields, "jsonpath_fields")
+	delete(tbl.Fields, "jsonpath_tags")
 	delete(tbl.Fields, "data_type")
 	delete(tbl.Fields, "collectd_auth_file")
 	delete(tbl.Fields, "collectd_security_level")
@@ -1853,6 +2141,12 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 	delete(tbl.Fields, "csv_timestamp_format")
 	delete(tbl.Fields, "csv_trim_space")
 	delete(tbl.Fields, "form_urlencoded_tag_keys")
+	delete(tbl.Fields, "table_column_names")
+	delete(tbl.Fields, "table_skip_rows")
+	delete(tbl.Fields, "table_tag_columns")
+	delete(tbl.Fields, "xml_query")
+	delete(tbl.Fields, "xml_fields")
+	delete(tbl.Fields, "xml_tags")
 
 	return c, nil
 }
@@ -2130,6 +2424,16 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["metric_batch_bytes"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			var size internal.Size
+			if err := size.UnmarshalTOML([]byte(kv.Value.Source())); err != nil {
+				return nil, fmt.Errorf("invalid metric_batch_bytes: %v", err)
+			}
+			oc.MetricBatchBytes = size.Size
+		}
+	}
+
 	if node, ok := tbl.Fields["alias"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -2162,14 +2466,28 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["accept_routes"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						oc.AcceptRoutes = append(oc.AcceptRoutes, str.Value)
+					}
+				}
+			}
+		}
+	}
+
 	delete(tbl.Fields, "flush_interval")
 	delete(tbl.Fields, "flush_jitter")
 	delete(tbl.Fields, "metric_buffer_limit")
+	delete(tbl.Fields, "metric_batch_bytes")
 	delete(tbl.Fields, "metric_batch_size")
 	delete(tbl.Fields, "alias")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_prefix")
+	delete(tbl.Fields, "accept_routes")
 
 	return oc, nil
 }
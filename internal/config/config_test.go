@@ -236,6 +236,46 @@ func TestConfig_InlineTables(t *testing.T) {
 	assert.Equal(t, []string{"org_id"}, c.Outputs[0].Config.Filter.TagInclude)
 }
 
+func TestConfig_MetricBatchBytes(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/metric_batch_bytes.toml")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(c.Outputs))
+
+	require.Equal(t, int64(1000*1000), c.Outputs[0].Config.MetricBatchBytes)
+	require.Equal(t, int64(2048), c.Outputs[1].Config.MetricBatchBytes)
+	require.Equal(t, int64(512), c.Outputs[2].Config.MetricBatchBytes)
+}
+
+func TestConfig_Routes(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/routes.toml")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(c.Inputs))
+	require.Equal(t, 2, len(c.Outputs))
+
+	require.Equal(t, "scripts", c.Inputs[0].Config.Route)
+	require.Equal(t, "", c.Inputs[1].Config.Route)
+	require.Equal(t, []string{"scripts", "default"}, c.Outputs[0].Config.AcceptRoutes)
+	require.Empty(t, c.Outputs[1].Config.AcceptRoutes)
+
+	// The options are not passed on to the plugins.
+	_, ok := c.Inputs[0].Input.(*memcached.Memcached)
+	require.True(t, ok)
+}
+
+func TestConfig_MetricFilter(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/metric_filter.toml")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(c.Inputs))
+
+	filter := c.Inputs[0].Config.Filter
+	require.True(t, filter.IsActive())
+	require.Equal(t, `name == "memcached"`, filter.MetricPass)
+	require.Equal(t, `fields.value < 0 or tags.status =~ "^error"`, filter.MetricDrop)
+}
+
 func TestConfig_SliceComment(t *testing.T) {
 	t.Skipf("Skipping until #3642 is resolved")
 
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+)
+
+var (
+	// encryptedRe is a regex to find encrypted values in the config file
+	encryptedRe = regexp.MustCompile(`ENC\[AES256,([A-Za-z0-9+/=]*)\]`)
+
+	errNoKey = errors.New("config contains encrypted values but no key file is set, use --config-key-file or $TELEGRAF_CONFIG_KEY_FILE")
+)
+
+// LoadKey reads the key for encrypted config values from a file.  The file
+// contains the 32 byte key in hex, as created by `openssl rand -hex 32`.
+func LoadKey(path string) ([]byte, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := hex.DecodeString(string(bytes.TrimSpace(contents)))
+	if err != nil {
+		return nil, fmt.Errorf("key in %s is not hex encoded: %v", path, err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key in %s must be 32 bytes, got %d", path, len(key))
+	}
+	return key, nil
+}
+
+// Encrypt encrypts a config value with AES-256-GCM and returns it in the
+// ENC[AES256,...] form understood by LoadConfig.
+func Encrypt(key []byte, plaintext []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+	return "ENC[AES256," + base64.StdEncoding.EncodeToString(sealed) + "]", nil
+}
+
+func decrypt(key []byte, encoded string) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("value is too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptValues replaces the encrypted values in the config with their
+// plaintext, the key is only loaded if there are any.
+func (c *Config) decryptValues(contents []byte) ([]byte, error) {
+	values := encryptedRe.FindAllSubmatch(contents, -1)
+	if len(values) == 0 {
+		return contents, nil
+	}
+
+	if c.key == nil {
+		path := c.KeyFile
+		if path == "" {
+			path = os.Getenv("TELEGRAF_CONFIG_KEY_FILE")
+		}
+		if path == "" {
+			return nil, errNoKey
+		}
+
+		key, err := LoadKey(path)
+		if err != nil {
+			return nil, err
+		}
+		c.key = key
+	}
+
+	for _, value := range values {
+		plaintext, err := decrypt(c.key, string(value[1]))
+		if err != nil {
+			return nil, fmt.Errorf("could not decrypt %s: %v", value[0], err)
+		}
+		contents = bytes.Replace(contents, value[0], []byte(escapeEnv(string(plaintext))), 1)
+	}
+	return contents, nil
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	httpOut "github.com/influxdata/telegraf/plugins/outputs/http"
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func writeFile(t *testing.T, dir, name, contents string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	key, err := LoadKey(writeFile(t, dir, "key", testKey+"\n"))
+	require.NoError(t, err)
+
+	encrypted, err := Encrypt(key, []byte("s3cr\"et"))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(encrypted, "ENC[AES256,"))
+
+	match := encryptedRe.FindStringSubmatch(encrypted)
+	require.Len(t, match, 2)
+	plaintext, err := decrypt(key, match[1])
+	require.NoError(t, err)
+	require.Equal(t, "s3cr\"et", string(plaintext))
+
+	// Values are encrypted with a random nonce.
+	again, err := Encrypt(key, []byte("s3cr\"et"))
+	require.NoError(t, err)
+	require.NotEqual(t, encrypted, again)
+}
+
+func TestConfig_EncryptedValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	key, err := LoadKey(writeFile(t, dir, "key", testKey))
+	require.NoError(t, err)
+	username, err := Encrypt(key, []byte("telegraf"))
+	require.NoError(t, err)
+	password, err := Encrypt(key, []byte(`pa$$"word`))
+	require.NoError(t, err)
+
+	path := writeFile(t, dir, "telegraf.conf", `
+[[outputs.http]]
+  url = "http://localhost:8080"
+  username = "`+username+`"
+  password = "`+password+`"
+`)
+
+	c := NewConfig()
+	c.KeyFile = filepath.Join(dir, "key")
+	require.NoError(t, c.LoadConfig(path))
+	require.Equal(t, 1, len(c.Outputs))
+
+	output, ok := c.Outputs[0].Output.(*httpOut.HTTP)
+	require.True(t, ok)
+	require.Equal(t, "telegraf", output.Username)
+	require.Equal(t, `pa$$"word`, output.Password)
+}
+
+func TestConfig_EncryptedValuesErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile := writeFile(t, dir, "key", testKey)
+	key, err := LoadKey(keyFile)
+	require.NoError(t, err)
+	password, err := Encrypt(key, []byte("password"))
+	require.NoError(t, err)
+
+	config := `
+[[outputs.http]]
+  url = "http://localhost:8080"
+  password = "%s"
+`
+	valid := writeFile(t, dir, "valid.conf", strings.Replace(config, "%s", password, 1))
+	tampered := writeFile(t, dir, "tampered.conf", strings.Replace(config, "%s", "ENC[AES256,AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA]", 1))
+
+	// No key file.
+	c := NewConfig()
+	require.Error(t, c.LoadConfig(valid))
+
+	// Key file from the environment.
+	require.NoError(t, os.Setenv("TELEGRAF_CONFIG_KEY_FILE", keyFile))
+	defer os.Unsetenv("TELEGRAF_CONFIG_KEY_FILE")
+	c = NewConfig()
+	require.NoError(t, c.LoadConfig(valid))
+
+	// Wrong key.
+	c = NewConfig()
+	c.KeyFile = writeFile(t, dir, "other", strings.Repeat("ab", 32))
+	require.Error(t, c.LoadConfig(valid))
+
+	c = NewConfig()
+	c.KeyFile = keyFile
+	require.Error(t, c.LoadConfig(tampered))
+
+	// Invalid keys.
+	_, err = LoadKey(writeFile(t, dir, "short", "0001"))
+	require.Error(t, err)
+	_, err = LoadKey(writeFile(t, dir, "nothex", strings.Repeat("zz", 32)))
+	require.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// remoteConfig is a config file loaded from a URL.
+type remoteConfig struct {
+	url  *url.URL
+	etag string
+	sum  [sha256.Size]byte
+}
+
+// HasRemoteConfig returns true if any of the config was loaded from a URL.
+func (c *Config) HasRemoteConfig() bool {
+	return len(c.remotes) > 0
+}
+
+// RemoteConfigChanged fetches the config files loaded from URLs again and
+// returns true if any of them changed.  The ETag of the last response is sent
+// so that servers supporting it can answer with 304 Not Modified, otherwise
+// the contents are compared.  A change is only reported once.
+func (c *Config) RemoteConfigChanged() (bool, error) {
+	changed := false
+	for _, remote := range c.remotes {
+		ok, err := remote.changed()
+		if err != nil {
+			return changed, fmt.Errorf("checking %s: %v", remote.url, err)
+		}
+		changed = changed || ok
+	}
+	return changed, nil
+}
+
+func (r *remoteConfig) changed() (bool, error) {
+	req, err := newConfigRequest(r.url)
+	if err != nil {
+		return false, err
+	}
+	if r.etag != "" {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+	default:
+		return false, fmt.Errorf("failed to retrieve remote config: %s", resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+
+	sum := sha256.Sum256(body)
+	r.etag = resp.Header.Get("ETag")
+	if sum == r.sum {
+		return false, nil
+	}
+	r.sum = sum
+	return true, nil
+}
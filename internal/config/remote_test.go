@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const remoteConfigFmt = `
+[[inputs.memcached]]
+  servers = ["%s"]
+`
+
+type configServer struct {
+	sync.Mutex
+	contents string
+	etag     bool
+}
+
+func (s *configServer) set(contents string) {
+	s.Lock()
+	defer s.Unlock()
+	s.contents = contents
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.etag {
+		etag := fmt.Sprintf(`"%x"`, len(s.contents))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+	}
+	w.Write([]byte(s.contents))
+}
+
+func TestConfig_RemoteConfigChanged(t *testing.T) {
+	for _, etag := range []bool{true, false} {
+		t.Run(fmt.Sprintf("etag %v", etag), func(t *testing.T) {
+			s := &configServer{
+				contents: fmt.Sprintf(remoteConfigFmt, "localhost"),
+				etag:     etag,
+			}
+			ts := httptest.NewServer(s)
+			defer ts.Close()
+
+			c := NewConfig()
+			require.NoError(t, c.LoadConfig(ts.URL))
+			require.True(t, c.HasRemoteConfig())
+
+			changed, err := c.RemoteConfigChanged()
+			require.NoError(t, err)
+			require.False(t, changed)
+
+			s.set(fmt.Sprintf(remoteConfigFmt, "remotehost"))
+			changed, err = c.RemoteConfigChanged()
+			require.NoError(t, err)
+			require.True(t, changed)
+
+			// A change is only reported once.
+			changed, err = c.RemoteConfigChanged()
+			require.NoError(t, err)
+			require.False(t, changed)
+		})
+	}
+}
+
+func TestConfig_RemoteConfigChangedError(t *testing.T) {
+	s := &configServer{contents: fmt.Sprintf(remoteConfigFmt, "localhost")}
+	ts := httptest.NewServer(s)
+
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig(ts.URL))
+	ts.Close()
+
+	_, err := c.RemoteConfigChanged()
+	require.Error(t, err)
+}
+
+func TestConfig_LocalConfigNotRemote(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/single_plugin.toml"))
+	require.False(t, c.HasRemoteConfig())
+}
@@ -0,0 +1,399 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/naoina/go-stringutil"
+)
+
+// Limits the nesting of sub-tables, guarding against recursive types.
+const maxSchemaDepth = 5
+
+var (
+	durationType = reflect.TypeOf(internal.Duration{})
+	sizeType     = reflect.TypeOf(internal.Size{})
+	timeType     = reflect.TypeOf(time.Duration(0))
+
+	// Matches an option in a sample config, such as `  # servers = [...]`,
+	// also when commented out in a commented out table.
+	sampleOptionRe = regexp.MustCompile(`^(?:#\s*)*([A-Za-z0-9_]+)\s*=`)
+	// Matches a table header in a sample config, such as
+	// `  # [[inputs.exec.entry]]`.
+	sampleTableRe = regexp.MustCompile(`^#?\s*\[\[?\s*([A-Za-z0-9_.-]+)\s*\]\]?$`)
+)
+
+// PluginSchema describes the configuration options of a plugin.
+type PluginSchema struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Options     []*OptionSchema `json:"options"`
+}
+
+// OptionSchema describes a single configuration option.  Type is one of
+// string, integer, float, boolean, duration, size, array or table.  Items is
+// the type of the elements of arrays and tables, Options describes the
+// options of a sub-table.
+type OptionSchema struct {
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Items       string          `json:"items,omitempty"`
+	Default     interface{}     `json:"default,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Options     []*OptionSchema `json:"options,omitempty"`
+}
+
+// NewPluginSchema builds the schema of a plugin from its struct fields.
+// Defaults are taken from the values set by the plugin creator, the option
+// descriptions from the comments in the sample config.
+func NewPluginSchema(pluginType string, name string, plugin printer) *PluginSchema {
+	docs := sampleConfigDocs(plugin.SampleConfig(), pluginType+"."+name)
+
+	v := reflect.ValueOf(plugin)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	options := []*OptionSchema{}
+	if v.Kind() == reflect.Struct {
+		options = structOptions(v, docs, "", 0)
+	}
+
+	switch plugin.(type) {
+	case parsers.ParserInput, parsers.ParserFuncInput, serializers.SerializerOutput:
+		options = append(options, &OptionSchema{
+			Name:        "data_format",
+			Type:        "string",
+			Default:     "influx",
+			Description: docs["data_format"],
+		})
+	}
+
+	return &PluginSchema{
+		Type:        pluginType,
+		Name:        name,
+		Description: plugin.Description(),
+		Options:     options,
+	}
+}
+
+// structOptions returns the options of a struct, documented by the docs of
+// the table with the prefix.
+func structOptions(v reflect.Value, docs map[string]string, prefix string, depth int) []*OptionSchema {
+	options := []*OptionSchema{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		key, ok := optionKey(field)
+		if !ok {
+			continue
+		}
+
+		fv := v.Field(i)
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+			if fv.IsValid() && !fv.IsNil() {
+				fv = fv.Elem()
+			} else {
+				fv = reflect.Value{}
+			}
+		}
+
+		// Options of embedded structs, such as the tls.ClientConfig, are
+		// set directly on the plugin table.
+		if field.Anonymous {
+			if ft.Kind() == reflect.Struct && depth < maxSchemaDepth {
+				if !fv.IsValid() {
+					fv = reflect.Zero(ft)
+				}
+				options = append(options, structOptions(fv, docs, prefix, depth+1)...)
+			}
+			continue
+		}
+
+		option := newOptionSchema(key, ft, fv, docs, prefix, depth)
+		if option == nil {
+			continue
+		}
+		option.Description = docs[prefix+key]
+		options = append(options, option)
+	}
+	return options
+}
+
+// optionKey returns the TOML key of a struct field, using the same rules as
+// the TOML decoder.
+func optionKey(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("toml")
+	if tag == "-" {
+		return "", false
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name, true
+	}
+	return stringutil.ToSnakeCase(field.Name), true
+}
+
+func newOptionSchema(key string, t reflect.Type, v reflect.Value, docs map[string]string, prefix string, depth int) *OptionSchema {
+	typ, ok := optionType(t)
+	if !ok {
+		return nil
+	}
+
+	option := &OptionSchema{
+		Name: key,
+		Type: typ,
+	}
+
+	switch typ {
+	case "array":
+		elem := t.Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		items, ok := optionType(elem)
+		if !ok {
+			return nil
+		}
+		option.Items = items
+		if items == "table" && elem.Kind() == reflect.Struct && depth < maxSchemaDepth {
+			option.Options = structOptions(reflect.Zero(elem), docs, prefix+key+".", depth+1)
+		}
+	case "table":
+		if t.Kind() == reflect.Map {
+			items, ok := optionType(t.Elem())
+			if !ok {
+				return nil
+			}
+			option.Items = items
+		} else if depth < maxSchemaDepth {
+			if !v.IsValid() {
+				v = reflect.Zero(t)
+			}
+			option.Options = structOptions(v, docs, prefix+key+".", depth+1)
+		}
+	}
+
+	if v.IsValid() {
+		option.Default = optionDefault(t, v)
+	}
+	return option
+}
+
+func optionType(t reflect.Type) (string, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case durationType, timeType:
+		return "duration", true
+	case sizeType:
+		return "size", true
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string", true
+	case reflect.Bool:
+		return "boolean", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer", true
+	case reflect.Float32, reflect.Float64:
+		return "float", true
+	case reflect.Slice, reflect.Array:
+		return "array", true
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return "", false
+		}
+		return "table", true
+	case reflect.Struct:
+		return "table", true
+	default:
+		return "", false
+	}
+}
+
+// optionDefault returns the value set by the plugin creator, or nil if the
+// option is unset.
+func optionDefault(t reflect.Type, v reflect.Value) interface{} {
+	if !v.CanInterface() {
+		return nil
+	}
+
+	switch t {
+	case durationType:
+		d := v.Interface().(internal.Duration).Duration
+		if d == 0 {
+			return nil
+		}
+		return d.String()
+	case timeType:
+		d := time.Duration(v.Int())
+		if d == 0 {
+			return nil
+		}
+		return d.String()
+	case sizeType:
+		size := v.Interface().(internal.Size).Size
+		if size == 0 {
+			return nil
+		}
+		return size
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return nil
+	case reflect.Slice, reflect.Map:
+		if v.Len() == 0 {
+			return nil
+		}
+	}
+
+	if reflect.DeepEqual(v.Interface(), reflect.Zero(t).Interface()) {
+		return nil
+	}
+	return v.Interface()
+}
+
+// sampleConfigDocs maps the options of a sample config to the "##" comment
+// lines preceding them.  The first occurrence of an option is used.  Options
+// of sub-tables, such as `[[inputs.exec.entry]]` of the table
+// "inputs.exec", are keyed by their path, "entry.command", and the table by
+// its own path.
+func sampleConfigDocs(config string, table string) map[string]string {
+	docs := make(map[string]string)
+	add := func(key string, comment []string) {
+		if _, ok := docs[key]; !ok && len(comment) > 0 {
+			docs[key] = strings.Join(comment, " ")
+		}
+	}
+
+	var (
+		comment []string
+		prefix  string
+	)
+	for _, line := range strings.Split(config, "\n") {
+		line = strings.TrimSpace(line)
+		// Comments of commented out sub-tables, such as `#   ## ...`.
+		uncommented := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		if uncommented == "" || strings.HasPrefix(uncommented, "##") {
+			line = uncommented
+		}
+
+		switch {
+		case line == "":
+			comment = nil
+		case strings.HasPrefix(line, "##"):
+			comment = append(comment, strings.TrimSpace(strings.TrimPrefix(line, "##")))
+		case sampleTableRe.MatchString(line):
+			name := sampleTableRe.FindStringSubmatch(line)[1]
+			if name == table {
+				prefix = ""
+			} else {
+				name = strings.TrimPrefix(name, table+".")
+				add(name, comment)
+				prefix = name + "."
+			}
+			comment = nil
+		default:
+			match := sampleOptionRe.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			add(prefix+match[1], comment)
+			comment = nil
+		}
+	}
+	return docs
+}
+
+// PluginSchemas returns the schema of every plugin, limited to the plugins
+// selected by the filters when they are set.
+func PluginSchemas(
+	sectionFilters []string,
+	inputFilters []string,
+	outputFilters []string,
+	aggregatorFilters []string,
+	processorFilters []string,
+) []*PluginSchema {
+	if len(sectionFilters) == 0 {
+		sectionFilters = sectionDefaults
+	}
+
+	schemas := []*PluginSchema{}
+	if sliceContains("inputs", sectionFilters) {
+		for _, name := range filteredNames(inputs.Inputs, inputFilters) {
+			schemas = append(schemas, NewPluginSchema("inputs", name, inputs.Inputs[name]()))
+		}
+	}
+	if sliceContains("outputs", sectionFilters) {
+		for _, name := range filteredNames(outputs.Outputs, outputFilters) {
+			schemas = append(schemas, NewPluginSchema("outputs", name, outputs.Outputs[name]()))
+		}
+	}
+	if sliceContains("processors", sectionFilters) {
+		for _, name := range filteredNames(processors.Processors, processorFilters) {
+			schemas = append(schemas, NewPluginSchema("processors", name, processors.Processors[name]()))
+		}
+	}
+	if sliceContains("aggregators", sectionFilters) {
+		for _, name := range filteredNames(aggregators.Aggregators, aggregatorFilters) {
+			schemas = append(schemas, NewPluginSchema("aggregators", name, aggregators.Aggregators[name]()))
+		}
+	}
+	return schemas
+}
+
+// filteredNames returns the sorted keys of a plugin registry.
+func filteredNames(registry interface{}, filters []string) []string {
+	var names []string
+	for _, k := range reflect.ValueOf(registry).MapKeys() {
+		name := k.String()
+		if len(filters) == 0 || sliceContains(name, filters) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PrintSchema prints the schema of the selected plugins as JSON.
+func PrintSchema(
+	sectionFilters []string,
+	inputFilters []string,
+	outputFilters []string,
+	aggregatorFilters []string,
+	processorFilters []string,
+) error {
+	schemas := PluginSchemas(sectionFilters, inputFilters, outputFilters,
+		aggregatorFilters, processorFilters)
+
+	octets, err := json.MarshalIndent(schemas, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(octets))
+	return nil
+}
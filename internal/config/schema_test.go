@@ -0,0 +1,150 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs/exec"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaTestPlugin struct {
+	Servers    []string          `toml:"servers"`
+	Timeout    internal.Duration `toml:"timeout"`
+	MaxSize    internal.Size
+	Retries    int
+	Ratio      float64
+	Enabled    bool `toml:"enabled"`
+	Headers    map[string]string
+	Interval   time.Duration
+	Pattern    *string
+	Endpoint   struct{ Path string }
+	Log        telegraf.Logger `toml:"-"`
+	Ignored    func()
+	unexported string
+	tls.ClientConfig
+}
+
+func (p *schemaTestPlugin) Description() string {
+	return "A plugin for testing schemas"
+}
+
+func (p *schemaTestPlugin) SampleConfig() string {
+	return `
+  ## Servers to connect to.
+  ## Use host:port.
+  servers = ["localhost:1234"]
+
+  ## Timeout of a request.
+  # timeout = "5s"
+
+  ## Unrelated comment
+
+  retries = 3
+`
+}
+
+func TestNewPluginSchema(t *testing.T) {
+	plugin := &schemaTestPlugin{
+		Servers: []string{"localhost:1234"},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+		Retries: 3,
+		Enabled: true,
+	}
+
+	schema := NewPluginSchema("inputs", "test", plugin)
+	require.Equal(t, "inputs", schema.Type)
+	require.Equal(t, "test", schema.Name)
+	require.Equal(t, "A plugin for testing schemas", schema.Description)
+
+	options := make(map[string]*OptionSchema)
+	for _, option := range schema.Options {
+		options[option.Name] = option
+	}
+	require.NotContains(t, options, "log")
+	require.NotContains(t, options, "ignored")
+	require.NotContains(t, options, "unexported")
+
+	require.Equal(t, &OptionSchema{
+		Name:        "servers",
+		Type:        "array",
+		Items:       "string",
+		Default:     []string{"localhost:1234"},
+		Description: "Servers to connect to. Use host:port.",
+	}, options["servers"])
+	require.Equal(t, &OptionSchema{
+		Name:        "timeout",
+		Type:        "duration",
+		Default:     "5s",
+		Description: "Timeout of a request.",
+	}, options["timeout"])
+	require.Equal(t, &OptionSchema{
+		Name:    "retries",
+		Type:    "integer",
+		Default: 3,
+	}, options["retries"])
+
+	require.Equal(t, "size", options["max_size"].Type)
+	require.Nil(t, options["max_size"].Default)
+	require.Equal(t, "float", options["ratio"].Type)
+	require.Equal(t, true, options["enabled"].Default)
+	require.Equal(t, "table", options["headers"].Type)
+	require.Equal(t, "string", options["headers"].Items)
+	require.Equal(t, "duration", options["interval"].Type)
+	require.Equal(t, "string", options["pattern"].Type)
+
+	require.Equal(t, "table", options["endpoint"].Type)
+	require.Equal(t, []*OptionSchema{{Name: "path", Type: "string"}},
+		options["endpoint"].Options)
+
+	// Options of embedded structs are part of the plugin table.
+	require.Equal(t, "string", options["tls_ca"].Type)
+	require.Equal(t, "boolean", options["insecure_skip_verify"].Type)
+}
+
+func TestPluginSchemas_Filters(t *testing.T) {
+	schemas := PluginSchemas([]string{"inputs"}, []string{"exec"}, nil, nil, nil)
+	require.Len(t, schemas, 1)
+	require.Equal(t, "exec", schemas[0].Name)
+
+	var names []string
+	for _, option := range schemas[0].Options {
+		names = append(names, option.Name)
+	}
+	require.Contains(t, names, "commands")
+	require.Contains(t, names, "data_format")
+}
+
+func TestNewPluginSchema_SubTables(t *testing.T) {
+	schema := NewPluginSchema("inputs", "exec", exec.NewExec())
+
+	options := make(map[string]*OptionSchema)
+	for _, option := range schema.Options {
+		options[option.Name] = option
+	}
+	require.Equal(t, "Timeout for each command to complete.  When it expires the command is sent SIGTERM, and SIGKILL if it is still running after kill_grace.",
+		options["timeout"].Description)
+	// The command of the entries does not document the legacy option.
+	require.Empty(t, options["command"].Description)
+
+	entry := options["entry"]
+	require.Equal(t, "array", entry.Type)
+	require.Equal(t, "table", entry.Items)
+	require.Contains(t, entry.Description, "Commands with their own measurement name and tags")
+
+	entryOptions := make(map[string]*OptionSchema)
+	for _, option := range entry.Options {
+		entryOptions[option.Name] = option
+	}
+	require.Equal(t, "string", entryOptions["command"].Type)
+	require.Equal(t, "duration", entryOptions["timeout"].Type)
+	require.Equal(t, "Overrides the timeout, kill_grace and stdin of the plugin.",
+		entryOptions["timeout"].Description)
+	require.Contains(t, entryOptions["schedule"].Description, "cron schedule")
+	require.Empty(t, entryOptions["name_override"].Description)
+	require.Equal(t, "table", entryOptions["tags"].Type)
+	require.Equal(t, "string", entryOptions["tags"].Items)
+}
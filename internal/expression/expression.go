@@ -0,0 +1,279 @@
+// Package expression implements the expressions over the name, tags and
+// fields of metrics, used by the metricpass and metricdrop filters and the
+// expression processor.
+package expression
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+// Condition is a boolean expression on the name, tags and fields of a metric.
+type Condition struct {
+	root condition
+}
+
+// CompileCondition parses a condition, ie:
+//
+//   c, _ := CompileCondition(`fields.value < 0 or tags.status =~ "^err"`)
+//   c.Match(m) // true if the value field is negative or the status tag
+//              // starts with "err"
+//
+// Comparisons are made against `name`, `tags.<key>` or `fields.<key>` and
+// can be combined with `and`, `or`, `not` and parentheses.  The operators
+// are ==, !=, <, <=, >, >=, =~ and !~, the last two take a regular
+// expression.  A comparison with a missing tag or field is false.
+func CompileCondition(expr string) (*Condition, error) {
+	p, err := newParser(expr)
+	if err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	return &Condition{root: root}, nil
+}
+
+// Match returns true if the metric matches the condition.
+func (c *Condition) Match(m telegraf.Metric) bool {
+	return c.root.match(m)
+}
+
+// Arithmetic is an arithmetic expression over the fields of a metric.
+type Arithmetic struct {
+	root value
+}
+
+// CompileArithmetic parses an arithmetic expression of field names and
+// numbers with the operators + - * / % and parentheses, ie:
+//
+//   a, _ := CompileArithmetic("used / total * 100")
+//
+// Field names may contain dashes, so the - operator must be separated from
+// them by spaces.
+func CompileArithmetic(expr string) (*Arithmetic, error) {
+	p, err := newParser(expr)
+	if err != nil {
+		return nil, err
+	}
+	root, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	return &Arithmetic{root: root}, nil
+}
+
+// Eval returns the value of the expression for the metric, false if a field
+// is missing or not numeric, or the result is not a number.
+func (a *Arithmetic) Eval(m telegraf.Metric) (float64, bool) {
+	return a.root.eval(m)
+}
+
+type condition interface {
+	match(m telegraf.Metric) bool
+}
+
+type andNode struct{ left, right condition }
+
+func (n *andNode) match(m telegraf.Metric) bool {
+	return n.left.match(m) && n.right.match(m)
+}
+
+type orNode struct{ left, right condition }
+
+func (n *orNode) match(m telegraf.Metric) bool {
+	return n.left.match(m) || n.right.match(m)
+}
+
+type notNode struct{ expr condition }
+
+func (n *notNode) match(m telegraf.Metric) bool {
+	return !n.expr.match(m)
+}
+
+type operandKind int
+
+const (
+	operandName operandKind = iota
+	operandTag
+	operandField
+)
+
+type comparison struct {
+	kind operandKind
+	key  string
+	op   string
+
+	str    string
+	num    float64
+	isNum  bool
+	boolv  bool
+	isBool bool
+	regex  *regexp.Regexp
+}
+
+func (c *comparison) match(m telegraf.Metric) bool {
+	var value interface{}
+	switch c.kind {
+	case operandName:
+		value = m.Name()
+	case operandTag:
+		v, ok := m.GetTag(c.key)
+		if !ok {
+			return false
+		}
+		value = v
+	case operandField:
+		v, ok := m.GetField(c.key)
+		if !ok {
+			return false
+		}
+		value = v
+	}
+
+	switch v := value.(type) {
+	case string:
+		return c.compareString(v)
+	case bool:
+		if !c.isBool {
+			return false
+		}
+		switch c.op {
+		case "==":
+			return v == c.boolv
+		case "!=":
+			return v != c.boolv
+		}
+		return false
+	}
+	if f, ok := internal.ToFloat64(value); ok {
+		return c.compareNumber(f)
+	}
+	return false
+}
+
+func (c *comparison) compareString(v string) bool {
+	switch c.op {
+	case "=~":
+		return c.regex.MatchString(v)
+	case "!~":
+		return !c.regex.MatchString(v)
+	}
+
+	// Tags hold numbers as strings, compare them as numbers.
+	if c.isNum {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return false
+		}
+		return c.compareNumber(f)
+	}
+	if c.isBool {
+		return false
+	}
+
+	switch c.op {
+	case "==":
+		return v == c.str
+	case "!=":
+		return v != c.str
+	}
+	return false
+}
+
+func (c *comparison) compareNumber(v float64) bool {
+	if !c.isNum {
+		return false
+	}
+	switch c.op {
+	case "==":
+		return v == c.num
+	case "!=":
+		return v != c.num
+	case "<":
+		return v < c.num
+	case "<=":
+		return v <= c.num
+	case ">":
+		return v > c.num
+	case ">=":
+		return v >= c.num
+	}
+	return false
+}
+
+// value is a part of an arithmetic expression.
+type value interface {
+	// eval returns false if a field is missing or not numeric, or the
+	// result is not a number.
+	eval(m telegraf.Metric) (float64, bool)
+}
+
+type number float64
+
+func (n number) eval(m telegraf.Metric) (float64, bool) {
+	return float64(n), true
+}
+
+type field string
+
+func (f field) eval(m telegraf.Metric) (float64, bool) {
+	v, ok := m.GetField(string(f))
+	if !ok {
+		return 0, false
+	}
+	return internal.ToFloat64(v)
+}
+
+type negate struct{ expr value }
+
+func (n *negate) eval(m telegraf.Metric) (float64, bool) {
+	v, ok := n.expr.eval(m)
+	return -v, ok
+}
+
+type binary struct {
+	op          string
+	left, right value
+}
+
+func (b *binary) eval(m telegraf.Metric) (float64, bool) {
+	left, ok := b.left.eval(m)
+	if !ok {
+		return 0, false
+	}
+	right, ok := b.right.eval(m)
+	if !ok {
+		return 0, false
+	}
+
+	var v float64
+	switch b.op {
+	case "+":
+		v = left + right
+	case "-":
+		v = left - right
+	case "*":
+		v = left * right
+	case "/":
+		v = left / right
+	case "%":
+		v = math.Mod(left, right)
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v, true
+}
@@ -0,0 +1,160 @@
+package expression
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionMatch(t *testing.T) {
+	m, err := metric.New("exec",
+		map[string]string{"status": "error_timeout", "port": "8080"},
+		map[string]interface{}{
+			"value":   int64(-3),
+			"latency": 12.5,
+			"count":   uint64(7),
+			"up":      true,
+			"state":   "degraded",
+		},
+		time.Unix(0, 0))
+	require.NoError(t, err)
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`name == "exec"`, true},
+		{`name != 'exec'`, false},
+		{`name =~ "^ex"`, true},
+		{`fields.value < 0`, true},
+		{`fields.value == -3`, true},
+		{`fields.value >= 0`, false},
+		{`fields.latency > 10 and fields.latency <= 12.5`, true},
+		{`fields.count == 7`, true},
+		{`fields.count != 7e0`, false},
+		{`fields.up == true`, true},
+		{`fields.up != true`, false},
+		{`fields.state == "degraded"`, true},
+		{`fields.state !~ "^ok$"`, true},
+		{`tags.status =~ "^error"`, true},
+		{`tags.status !~ "^error"`, false},
+		{`tags.port > 1024`, true},
+		{`tags.port == "8080"`, true},
+		{`tags.missing == ""`, false},
+		{`tags.missing != "x"`, false},
+		{`fields.missing < 0`, false},
+		{`not fields.missing < 0`, true},
+		{`fields.state > 1`, false},
+		{`fields.value < 0 or tags.status =~ "^ok"`, true},
+		{`fields.value > 0 or tags.status =~ "^ok"`, false},
+		{`not (fields.value > 0 or tags.status =~ "^ok")`, true},
+		{`fields.value > 0 or fields.up == true and tags.port == "8080"`, true},
+		{`(fields.value > 0 or fields.up == true) and tags.port == "9090"`, false},
+		{`tags.status =~ 'error_\w+'`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := CompileCondition(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, e.Match(m))
+		})
+	}
+}
+
+func TestCompileConditionErrors(t *testing.T) {
+	tests := []string{
+		``,
+		`value < 0`,
+		`fields. < 0`,
+		`fields.value`,
+		`fields.value <`,
+		`fields.value < "a"`,
+		`fields.value =~ 1`,
+		`tags.status =~ "("`,
+		`fields.up > true`,
+		`(fields.value < 0`,
+		`fields.value < 0)`,
+		`fields.value < 0 and`,
+		`tags.status == "unterminated`,
+		`fields.value ? 0`,
+		`fields.value < -"a"`,
+		`fields.value + 1 < 0`,
+	}
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			_, err := CompileCondition(expr)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestArithmeticEval(t *testing.T) {
+	m, err := metric.New("test",
+		map[string]string{},
+		map[string]interface{}{
+			"a":     float64(6),
+			"b":     int64(4),
+			"x.y_z": float64(0.5),
+			"c-d":   uint64(2),
+		},
+		time.Unix(0, 0))
+	require.NoError(t, err)
+
+	tests := []struct {
+		expr     string
+		expected float64
+	}{
+		{"a + b * 2", 14},
+		{"(a + b) * 2", 20},
+		{"a - b - 1", 1},
+		{"a / b", 1.5},
+		{"a % b", 2},
+		{"-a + b", -2},
+		{"a - -b", 10},
+		{"x.y_z * 10", 5},
+		{"c-d * 2", 4},
+		{" 1.5 ", 1.5},
+		{".5e1", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			a, err := CompileArithmetic(tt.expr)
+			require.NoError(t, err)
+			v, ok := a.Eval(m)
+			require.True(t, ok)
+			require.Equal(t, tt.expected, v)
+		})
+	}
+}
+
+func TestArithmeticEvalInvalid(t *testing.T) {
+	m, err := metric.New("test",
+		map[string]string{},
+		map[string]interface{}{
+			"a":      float64(6),
+			"status": "ok",
+		},
+		time.Unix(0, 0))
+	require.NoError(t, err)
+
+	for _, expr := range []string{"a / missing", "a + status", "a / (a - a)"} {
+		t.Run(expr, func(t *testing.T) {
+			a, err := CompileArithmetic(expr)
+			require.NoError(t, err)
+			_, ok := a.Eval(m)
+			require.False(t, ok)
+		})
+	}
+}
+
+func TestCompileArithmeticErrors(t *testing.T) {
+	for _, expr := range []string{"", "a +", "(a + b", "a b", "a ^ b", "1.2.3", "a < b", `"a" + 1`} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := CompileArithmetic(expr)
+			require.Error(t, err)
+		})
+	}
+}
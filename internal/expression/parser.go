@@ -0,0 +1,365 @@
+package expression
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type tokenType int
+
+const (
+	tokenIdent tokenType = iota
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	typ  tokenType
+	text string
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "=~", "!~", "<", ">", "+", "-", "*", "/", "%"}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenLParen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRParen, ")"})
+			i++
+		case c == '"' || c == '\'':
+			s, n, err := readString(expr[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokenString, s})
+			i += n
+		case isDigit(c) || c == '.' && i+1 < len(expr) && isDigit(expr[i+1]):
+			j := i + 1
+			for j < len(expr) && (isDigit(expr[j]) || strings.IndexByte(".eE", expr[j]) >= 0 ||
+				(expr[j] == '-' || expr[j] == '+') && (expr[j-1] == 'e' || expr[j-1] == 'E')) {
+				j++
+			}
+			tokens = append(tokens, token{tokenNumber, expr[i:j]})
+			i = j
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(expr) && isIdent(expr[j]) {
+				j++
+			}
+			tokens = append(tokens, token{tokenIdent, expr[i:j]})
+			i = j
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(expr[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+			tokens = append(tokens, token{tokenOperator, op})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+// readString reads a quoted string, a backslash escapes the next character.
+func readString(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				if s[i] != quote && s[i] != '\\' {
+					b.WriteByte('\\')
+				}
+				b.WriteByte(s[i])
+			}
+		case quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string %s", s)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isIdent(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '.' || c == '-'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func newParser(expr string) (*parser, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{tokens: tokens}, nil
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() (token, error) {
+	if p.done() {
+		return token{}, fmt.Errorf("unexpected end of expression")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t, nil
+}
+
+func (p *parser) keyword(word string) bool {
+	if !p.done() && p.peek().typ == tokenIdent && p.peek().text == word {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// operator consumes the next token if it is one of the operators.
+func (p *parser) operator(ops ...string) (string, bool) {
+	if p.done() || p.peek().typ != tokenOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if p.peek().text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (condition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (condition, error) {
+	if p.keyword("not") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{expr}, nil
+	}
+
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if t.typ == tokenLParen {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t, err := p.next(); err != nil || t.typ != tokenRParen {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return expr, nil
+	}
+	return p.parseComparison(t)
+}
+
+func (p *parser) parseComparison(operand token) (condition, error) {
+	if operand.typ != tokenIdent {
+		return nil, fmt.Errorf("expected name, tags.<key> or fields.<key>, got %q", operand.text)
+	}
+
+	c := &comparison{}
+	switch {
+	case operand.text == "name":
+		c.kind = operandName
+	case strings.HasPrefix(operand.text, "tags.") && len(operand.text) > len("tags."):
+		c.kind = operandTag
+		c.key = strings.TrimPrefix(operand.text, "tags.")
+	case strings.HasPrefix(operand.text, "fields.") && len(operand.text) > len("fields."):
+		c.kind = operandField
+		c.key = strings.TrimPrefix(operand.text, "fields.")
+	default:
+		return nil, fmt.Errorf("expected name, tags.<key> or fields.<key>, got %q", operand.text)
+	}
+
+	op, ok := p.operator("==", "!=", "<=", ">=", "=~", "!~", "<", ">")
+	if !ok {
+		if p.done() {
+			return nil, fmt.Errorf("unexpected end of expression")
+		}
+		return nil, fmt.Errorf("expected operator after %q, got %q", operand.text, p.peek().text)
+	}
+	c.op = op
+
+	// A sign is a separate token, join it with the number.
+	sign, _ := p.operator("-", "+")
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if sign != "" && value.typ != tokenNumber {
+		return nil, fmt.Errorf("invalid value %q for operator %s", sign+value.text, c.op)
+	}
+
+	switch c.op {
+	case "=~", "!~":
+		if value.typ != tokenString {
+			return nil, fmt.Errorf("operator %s requires a quoted regular expression", c.op)
+		}
+		c.regex, err = regexp.Compile(value.text)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+
+	switch {
+	case value.typ == tokenNumber:
+		c.num, err = strconv.ParseFloat(sign+value.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", sign+value.text)
+		}
+		c.isNum = true
+	case value.typ == tokenString && (c.op == "==" || c.op == "!="):
+		c.str = value.text
+	case value.typ == tokenIdent && (value.text == "true" || value.text == "false") &&
+		(c.op == "==" || c.op == "!="):
+		c.boolv = value.text == "true"
+		c.isBool = true
+	default:
+		return nil, fmt.Errorf("invalid value %q for operator %s", value.text, c.op)
+	}
+	return c, nil
+}
+
+func (p *parser) parseSum() (value, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.operator("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op, left, right}
+	}
+}
+
+func (p *parser) parseProduct() (value, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.operator("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op, left, right}
+	}
+}
+
+func (p *parser) parseUnary() (value, error) {
+	if _, ok := p.operator("-"); ok {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &negate{expr}, nil
+	}
+	return p.parseOperand()
+}
+
+func (p *parser) parseOperand() (value, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch t.typ {
+	case tokenLParen:
+		expr, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if t, err := p.next(); err != nil || t.typ != tokenRParen {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return expr, nil
+	case tokenNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", t.text)
+		}
+		return number(v), nil
+	case tokenIdent:
+		return field(t.text), nil
+	}
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
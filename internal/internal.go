@@ -287,6 +287,20 @@ func ExitStatus(err error) (int, bool) {
 	return 0, false
 }
 
+// ToFloat64 returns the value of a numeric field as a float, and false if the
+// field is not an integer, unsigned or float.
+func ToFloat64(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
 func (r *ReadWaitCloser) Close() error {
 	err := r.pipeReader.Close()
 	r.wg.Wait() // wait for the gzip goroutine finish
@@ -480,3 +480,22 @@ func TestParseTimestamp(t *testing.T) {
 		})
 	}
 }
+
+func TestToFloat64(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected float64
+		ok       bool
+	}{
+		{int64(-2), -2, true},
+		{uint64(3), 3, true},
+		{4.5, 4.5, true},
+		{"5", 0, false},
+		{true, 0, false},
+	}
+	for _, tt := range tests {
+		v, ok := ToFloat64(tt.value)
+		require.Equal(t, tt.ok, ok)
+		require.Equal(t, tt.expected, v)
+	}
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+// Errors are counted over this window for the error limit.
+const circuitBreakerWindow = time.Minute
+
+// circuitBreaker pauses a plugin once it logs more than limit errors within a
+// minute.  While paused errors are not counted, after the cooldown the count
+// starts over.
+type circuitBreaker struct {
+	sync.Mutex
+	limit    int
+	cooldown time.Duration
+
+	errors      []time.Time
+	pausedUntil time.Time
+
+	now func() time.Time
+}
+
+func newCircuitBreaker(limit int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		limit:    limit,
+		cooldown: cooldown,
+		now:      time.Now,
+	}
+}
+
+// error records an error and returns true if it tripped the breaker.
+func (b *circuitBreaker) error() bool {
+	b.Lock()
+	defer b.Unlock()
+
+	now := b.now()
+	if now.Before(b.pausedUntil) {
+		return false
+	}
+
+	// Forget the errors that are outside of the window.
+	i := 0
+	for i < len(b.errors) && now.Sub(b.errors[i]) >= circuitBreakerWindow {
+		i++
+	}
+	b.errors = append(b.errors[i:], now)
+
+	if len(b.errors) <= b.limit {
+		return false
+	}
+	b.errors = b.errors[:0]
+	b.pausedUntil = now.Add(b.cooldown)
+	return true
+}
+
+// paused returns true if the breaker tripped within the cooldown.
+func (b *circuitBreaker) paused() bool {
+	b.Lock()
+	defer b.Unlock()
+	return b.now().Before(b.pausedUntil)
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newCircuitBreaker(2, 5*time.Minute)
+	b.now = func() time.Time { return now }
+
+	require.False(t, b.error())
+	require.False(t, b.error())
+	require.False(t, b.paused())
+	require.True(t, b.error())
+	require.True(t, b.paused())
+
+	// Errors while paused are not counted.
+	now = now.Add(time.Minute)
+	require.False(t, b.error())
+	require.True(t, b.paused())
+
+	now = now.Add(5 * time.Minute)
+	require.False(t, b.paused())
+	require.False(t, b.error())
+	require.False(t, b.error())
+	require.True(t, b.error())
+}
+
+func TestCircuitBreaker_Window(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newCircuitBreaker(2, 5*time.Minute)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		require.False(t, b.error())
+		now = now.Add(30 * time.Second)
+	}
+	require.False(t, b.paused())
+}
@@ -0,0 +1,358 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	serializer "github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const (
+	// Size after which the disk buffer starts a new segment file.
+	diskBufferSegmentSize = 1024 * 1024
+
+	diskBufferSegmentExt = ".lp"
+)
+
+var errDiskBufferFull = errors.New("disk buffer full")
+
+type diskSegment struct {
+	path  string
+	seq   int64
+	size  int64
+	count int
+}
+
+// DiskBuffer stores metrics in line protocol segment files within a
+// directory.  Metrics are read back oldest segment first.  A segment is only
+// removed once all of its metrics have been accepted, so metrics may be
+// written more than once if telegraf stops while a segment is being sent.
+type DiskBuffer struct {
+	sync.Mutex
+	dir      string
+	limit    int64
+	segments []*diskSegment // ordered oldest to newest
+	file     *os.File       // open for appending to the newest segment
+	size     int64          // total size of all segments in bytes
+	count    int            // number of metrics not yet accepted
+	nextSeq  int64
+
+	pending    []telegraf.Metric // unsent metrics of the oldest segment
+	batchSize  int
+	serializer *serializer.Serializer
+
+	MetricsSpooled selfstat.Stat
+	MetricsWritten selfstat.Stat
+	MetricsDropped selfstat.Stat
+	DiskBufferSize selfstat.Stat
+}
+
+// NewDiskBuffer opens the disk buffer in dir, creating the directory if
+// needed.  Existing segments are kept so that they are sent after a restart.
+// A limit of 0 disables the size limit.
+func NewDiskBuffer(dir string, limit int64, name string, alias string) (*DiskBuffer, error) {
+	tags := map[string]string{"output": name}
+	if alias != "" {
+		tags["alias"] = alias
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	s := serializer.NewSerializer()
+	s.SetFieldTypeSupport(serializer.UintSupport)
+
+	b := &DiskBuffer{
+		dir:        dir,
+		limit:      limit,
+		serializer: s,
+
+		MetricsSpooled: selfstat.Register(
+			"write",
+			"metrics_spooled",
+			tags,
+		),
+		MetricsWritten: selfstat.Register(
+			"write",
+			"metrics_written",
+			tags,
+		),
+		MetricsDropped: selfstat.Register(
+			"write",
+			"metrics_dropped",
+			tags,
+		),
+		DiskBufferSize: selfstat.Register(
+			"write",
+			"disk_buffer_size",
+			tags,
+		),
+	}
+
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	b.DiskBufferSize.Set(int64(b.count))
+	return b, nil
+}
+
+// load finds the segments left behind by a previous run.
+func (b *DiskBuffer) load() error {
+	files, err := ioutil.ReadDir(b.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, diskBufferSegmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseInt(strings.TrimSuffix(name, diskBufferSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		path := filepath.Join(b.dir, name)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		b.segments = append(b.segments, &diskSegment{
+			path:  path,
+			seq:   seq,
+			size:  fi.Size(),
+			count: bytes.Count(data, []byte{'\n'}),
+		})
+		b.size += fi.Size()
+		b.count += bytes.Count(data, []byte{'\n'})
+		if seq >= b.nextSeq {
+			b.nextSeq = seq + 1
+		}
+	}
+
+	sort.Slice(b.segments, func(i, j int) bool {
+		return b.segments[i].seq < b.segments[j].seq
+	})
+	return nil
+}
+
+// Len returns the number of metrics in the disk buffer.
+func (b *DiskBuffer) Len() int {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.count
+}
+
+// Add appends metrics to the newest segment and returns the number of dropped
+// metrics.  Stored metrics are accepted, since they will be delivered from
+// the disk buffer, and metrics that do not fit within the size limit are
+// rejected.
+func (b *DiskBuffer) Add(metrics ...telegraf.Metric) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	dropped := 0
+	var err error
+	for _, m := range metrics {
+		// After an I/O error the remaining metrics are dropped.
+		if err == nil {
+			err = b.add(m)
+			if err == nil {
+				b.MetricsSpooled.Incr(1)
+				m.Accept()
+				continue
+			}
+			if err == errDiskBufferFull {
+				err = nil
+			}
+		}
+		dropped++
+		m.Reject()
+	}
+
+	b.metricsDropped(dropped)
+	b.DiskBufferSize.Set(int64(b.count))
+	return dropped, err
+}
+
+func (b *DiskBuffer) add(m telegraf.Metric) error {
+	octets, err := b.serializer.Serialize(m)
+	if err != nil {
+		return errDiskBufferFull
+	}
+
+	if b.limit > 0 && b.size+int64(len(octets)) > b.limit {
+		return errDiskBufferFull
+	}
+	return b.append(octets)
+}
+
+func (b *DiskBuffer) append(octets []byte) error {
+	var current *diskSegment
+	if len(b.segments) > 0 && b.file != nil {
+		current = b.segments[len(b.segments)-1]
+	}
+
+	if current == nil || current.size >= diskBufferSegmentSize {
+		if err := b.closeFile(); err != nil {
+			return err
+		}
+
+		path := filepath.Join(b.dir, fmt.Sprintf("%020d%s", b.nextSeq, diskBufferSegmentExt))
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		b.file = file
+		current = &diskSegment{path: path, seq: b.nextSeq}
+		b.segments = append(b.segments, current)
+		b.nextSeq++
+	}
+
+	n, err := b.file.Write(octets)
+	current.size += int64(n)
+	b.size += int64(n)
+	if err != nil {
+		return err
+	}
+	current.count++
+	b.count++
+	return nil
+}
+
+// Batch returns up to batchSize of the oldest metrics.  The batch must be
+// passed to Accept or Reject before the next call to Batch.
+func (b *DiskBuffer) Batch(batchSize int) ([]telegraf.Metric, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	for len(b.pending) == 0 {
+		if len(b.segments) == 0 {
+			return nil, nil
+		}
+
+		// Appends continue in a new segment once the oldest one is read.
+		if len(b.segments) == 1 {
+			if err := b.closeFile(); err != nil {
+				return nil, err
+			}
+		}
+
+		metrics, err := b.read(b.segments[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(metrics) == 0 {
+			if err := b.removeOldest(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		b.pending = metrics
+	}
+
+	b.batchSize = min(len(b.pending), batchSize)
+	batch := make([]telegraf.Metric, b.batchSize)
+	copy(batch, b.pending)
+	return batch, nil
+}
+
+func (b *DiskBuffer) read(segment *diskSegment) ([]telegraf.Metric, error) {
+	data, err := ioutil.ReadFile(segment.path)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := influx.NewParser(influx.NewMetricHandler())
+	parser.OnParseError = influx.OnParseErrorDropLine
+	metrics, err := parser.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lines that could not be parsed are lost, stop counting them.
+	if lost := segment.count - len(metrics); lost > 0 {
+		b.count -= lost
+		segment.count = len(metrics)
+		b.metricsDropped(lost)
+	}
+	return metrics, nil
+}
+
+// Accept marks the batch, acquired from Batch(), as successfully written.
+func (b *DiskBuffer) Accept(batch []telegraf.Metric) error {
+	b.Lock()
+	defer b.Unlock()
+
+	n := min(len(batch), b.batchSize)
+	b.pending = b.pending[n:]
+	b.batchSize = 0
+	b.count -= n
+	b.segments[0].count -= n
+	b.MetricsWritten.Incr(int64(n))
+	AgentMetricsWritten.Incr(int64(n))
+
+	var err error
+	if len(b.pending) == 0 {
+		b.pending = nil
+		err = b.removeOldest()
+	}
+	b.DiskBufferSize.Set(int64(b.count))
+	return err
+}
+
+// Reject returns the batch, acquired from Batch(), to the buffer.
+func (b *DiskBuffer) Reject(batch []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.batchSize = 0
+}
+
+func (b *DiskBuffer) removeOldest() error {
+	oldest := b.segments[0]
+	b.segments = b.segments[1:]
+	b.size -= oldest.size
+	b.count -= oldest.count
+	return os.Remove(oldest.path)
+}
+
+func (b *DiskBuffer) metricsDropped(n int) {
+	if n == 0 {
+		return
+	}
+	AgentMetricsDropped.Incr(int64(n))
+	b.MetricsDropped.Incr(int64(n))
+}
+
+func (b *DiskBuffer) closeFile() error {
+	if b.file == nil {
+		return nil
+	}
+	err := b.file.Close()
+	b.file = nil
+	return err
+}
+
+// Close closes the open segment, the buffered metrics remain on disk.
+func (b *DiskBuffer) Close() error {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.closeFile()
+}
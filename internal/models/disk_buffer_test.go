@@ -0,0 +1,173 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiskBuffer(t *testing.T, dir string, limit int64) *DiskBuffer {
+	b, err := NewDiskBuffer(dir, limit, "disktest", "")
+	require.NoError(t, err)
+	b.MetricsSpooled.Set(0)
+	b.MetricsWritten.Set(0)
+	b.MetricsDropped.Set(0)
+	return b
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "disk_buffer")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestDiskBuffer_AddBatchAccept(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 0)
+	defer b.Close()
+
+	var accepted int
+	for i := int64(1); i <= 3; i++ {
+		m := &MockMetric{
+			Metric:  MetricTime(i),
+			AcceptF: func() { accepted++ },
+		}
+		dropped, err := b.Add(m)
+		require.NoError(t, err)
+		require.Equal(t, 0, dropped)
+	}
+	require.Equal(t, 3, accepted)
+	require.Equal(t, 3, b.Len())
+	require.Equal(t, int64(3), b.MetricsSpooled.Get())
+
+	batch, err := b.Batch(2)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(1), MetricTime(2)}, batch)
+	require.NoError(t, b.Accept(batch))
+	require.Equal(t, 1, b.Len())
+
+	batch, err = b.Batch(2)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{MetricTime(3)}, batch)
+	require.NoError(t, b.Accept(batch))
+	require.Equal(t, 0, b.Len())
+	require.Equal(t, int64(3), b.MetricsWritten.Get())
+
+	batch, err = b.Batch(2)
+	require.NoError(t, err)
+	require.Len(t, batch, 0)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 0)
+}
+
+func TestDiskBuffer_Reject(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 0)
+	defer b.Close()
+
+	_, err := b.Add(MetricTime(1), MetricTime(2))
+	require.NoError(t, err)
+
+	batch, err := b.Batch(1)
+	require.NoError(t, err)
+	b.Reject(batch)
+	require.Equal(t, 2, b.Len())
+
+	batch, err = b.Batch(2)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(1), MetricTime(2)}, batch)
+}
+
+func TestDiskBuffer_AddWhileReading(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 0)
+	defer b.Close()
+
+	_, err := b.Add(MetricTime(1))
+	require.NoError(t, err)
+
+	batch, err := b.Batch(1)
+	require.NoError(t, err)
+
+	_, err = b.Add(MetricTime(2))
+	require.NoError(t, err)
+	require.NoError(t, b.Accept(batch))
+	require.Equal(t, 1, b.Len())
+
+	batch, err = b.Batch(1)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{MetricTime(2)}, batch)
+}
+
+func TestDiskBuffer_Reopen(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 0)
+	_, err := b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	require.NoError(t, err)
+
+	batch, err := b.Batch(1)
+	require.NoError(t, err)
+	require.NoError(t, b.Accept(batch))
+	require.NoError(t, b.Close())
+
+	// Metrics of a partially sent segment are sent again.
+	b = newTestDiskBuffer(t, dir, 0)
+	defer b.Close()
+	require.Equal(t, 3, b.Len())
+
+	_, err = b.Add(MetricTime(4))
+	require.NoError(t, err)
+
+	var metrics []telegraf.Metric
+	for {
+		batch, err := b.Batch(10)
+		require.NoError(t, err)
+		if len(batch) == 0 {
+			break
+		}
+		metrics = append(metrics, batch...)
+		require.NoError(t, b.Accept(batch))
+	}
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(1), MetricTime(2), MetricTime(3), MetricTime(4)},
+		metrics)
+}
+
+func TestDiskBuffer_Limit(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	octets, err := newTestDiskBuffer(t, dir, 0).serializer.Serialize(MetricTime(1))
+	require.NoError(t, err)
+
+	b := newTestDiskBuffer(t, dir, int64(2*len(octets)))
+	defer b.Close()
+
+	var rejected int
+	m := &MockMetric{
+		Metric:  MetricTime(3),
+		RejectF: func() { rejected++ },
+	}
+	dropped, err := b.Add(MetricTime(1), MetricTime(2), m)
+	require.NoError(t, err)
+	require.Equal(t, 1, dropped)
+	require.Equal(t, 1, rejected)
+	require.Equal(t, 2, b.Len())
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+}
@@ -34,6 +34,11 @@ type Filter struct {
 	TagInclude []string
 	tagInclude filter.Filter
 
+	MetricDrop string
+	metricDrop *filter.Expression
+	MetricPass string
+	metricPass *filter.Expression
+
 	isActive bool
 }
 
@@ -46,7 +51,9 @@ func (f *Filter) Compile() error {
 		len(f.TagInclude) == 0 &&
 		len(f.TagExclude) == 0 &&
 		len(f.TagPass) == 0 &&
-		len(f.TagDrop) == 0 {
+		len(f.TagDrop) == 0 &&
+		f.MetricDrop == "" &&
+		f.MetricPass == "" {
 		return nil
 	}
 
@@ -91,11 +98,25 @@ func (f *Filter) Compile() error {
 			return fmt.Errorf("Error compiling 'tagpass', %s", err)
 		}
 	}
+
+	if f.MetricDrop != "" {
+		f.metricDrop, err = filter.CompileExpression(f.MetricDrop)
+		if err != nil {
+			return fmt.Errorf("Error compiling 'metricdrop', %s", err)
+		}
+	}
+	if f.MetricPass != "" {
+		f.metricPass, err = filter.CompileExpression(f.MetricPass)
+		if err != nil {
+			return fmt.Errorf("Error compiling 'metricpass', %s", err)
+		}
+	}
 	return nil
 }
 
 // Select returns true if the metric matches according to the
-// namepass/namedrop and tagpass/tagdrop filters.  The metric is not modified.
+// namepass/namedrop, tagpass/tagdrop and metricpass/metricdrop filters.  The
+// metric is not modified.
 func (f *Filter) Select(metric telegraf.Metric) bool {
 	if !f.isActive {
 		return true
@@ -109,6 +130,10 @@ func (f *Filter) Select(metric telegraf.Metric) bool {
 		return false
 	}
 
+	if !f.shouldMetricPass(metric) {
+		return false
+	}
+
 	return true
 }
 
@@ -219,6 +244,18 @@ func (f *Filter) shouldTagsPass(tags []*telegraf.Tag) bool {
 	return true
 }
 
+// shouldMetricPass returns true if the metric should pass, false if should
+// drop based on the metricpass/metricdrop expressions
+func (f *Filter) shouldMetricPass(metric telegraf.Metric) bool {
+	if f.metricPass != nil && !f.metricPass.Match(metric) {
+		return false
+	}
+	if f.metricDrop != nil && f.metricDrop.Match(metric) {
+		return false
+	}
+	return true
+}
+
 // filterFields removes fields according to fieldpass/fielddrop.
 func (f *Filter) filterFields(metric telegraf.Metric) {
 	filterKeys := []string{}
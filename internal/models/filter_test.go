@@ -482,6 +482,59 @@ func TestFilter_FilterTagsPassAndDrop(t *testing.T) {
 
 }
 
+func TestFilter_MetricPassAndDrop(t *testing.T) {
+	f := Filter{
+		MetricPass: `name == "exec" or tags.source =~ "^script"`,
+		MetricDrop: `fields.value < 0`,
+	}
+	require.NoError(t, f.Compile())
+	require.True(t, f.IsActive())
+
+	tests := []struct {
+		metric   telegraf.Metric
+		expected bool
+	}{
+		{
+			metric: testutil.MustMetric("exec",
+				map[string]string{},
+				map[string]interface{}{"value": int64(1)},
+				time.Unix(0, 0)),
+			expected: true,
+		},
+		{
+			metric: testutil.MustMetric("exec",
+				map[string]string{},
+				map[string]interface{}{"value": int64(-1)},
+				time.Unix(0, 0)),
+			expected: false,
+		},
+		{
+			metric: testutil.MustMetric("cpu",
+				map[string]string{"source": "script_a"},
+				map[string]interface{}{"value": 1.5},
+				time.Unix(0, 0)),
+			expected: true,
+		},
+		{
+			metric: testutil.MustMetric("cpu",
+				map[string]string{"source": "host"},
+				map[string]interface{}{"value": 1.5},
+				time.Unix(0, 0)),
+			expected: false,
+		},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, f.Select(tt.metric))
+	}
+}
+
+func TestFilter_MetricDropError(t *testing.T) {
+	f := Filter{
+		MetricDrop: `fields.value <`,
+	}
+	require.Error(t, f.Compile())
+}
+
 func BenchmarkFilter(b *testing.B) {
 	tests := []struct {
 		name   string
@@ -72,6 +72,8 @@ type AggregatorConfig struct {
 	Period       time.Duration
 	Delay        time.Duration
 	Grace        time.Duration
+	// Route is set on all metrics pushed by the aggregator.
+	Route string
 
 	NameOverride      string
 	MeasurementPrefix string
@@ -119,6 +121,9 @@ func (r *RunningAggregator) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 
 	if m != nil {
 		m.SetAggregate(true)
+		if r.Config.Route != "" {
+			m.SetRoute(r.Config.Route)
+		}
 	}
 
 	r.MetricsPushed.Incr(1)
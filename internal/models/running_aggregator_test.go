@@ -38,6 +38,22 @@ func TestAdd(t *testing.T) {
 	require.Equal(t, int64(101), acc.Metrics[0].Fields["sum"])
 }
 
+func TestAggregatorMakeMetricRoute(t *testing.T) {
+	ra := NewRunningAggregator(&TestAggregator{}, &AggregatorConfig{
+		Name:  "TestRunningAggregator",
+		Route: "aggregates",
+	})
+
+	m := ra.MakeMetric(testutil.MustMetric("TestAggregator",
+		map[string]string{},
+		map[string]interface{}{
+			"sum": int64(101),
+		},
+		time.Now()))
+	require.True(t, m.IsAggregate())
+	require.Equal(t, "aggregates", m.Route())
+}
+
 func TestAddMetricsOutsideCurrentPeriod(t *testing.T) {
 	a := &TestAggregator{}
 	ra := NewRunningAggregator(a, &AggregatorConfig{
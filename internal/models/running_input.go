@@ -18,9 +18,12 @@ type RunningInput struct {
 
 	log         telegraf.Logger
 	defaultTags map[string]string
+	breaker     *circuitBreaker
 
 	MetricsGathered selfstat.Stat
 	GatherTime      selfstat.Stat
+	Paused          selfstat.Stat
+	Pauses          selfstat.Stat
 }
 
 func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
@@ -37,7 +40,7 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 	})
 	setLogIfExist(input, logger)
 
-	return &RunningInput{
+	r := &RunningInput{
 		Input:  input,
 		Config: config,
 		MetricsGathered: selfstat.Register(
@@ -52,6 +55,40 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 		),
 		log: logger,
 	}
+
+	if config.ErrorLimit > 0 {
+		r.breaker = newCircuitBreaker(config.ErrorLimit, config.ErrorCooldown)
+		r.Paused = selfstat.Register("gather", "paused", tags)
+		r.Pauses = selfstat.Register("gather", "pauses", tags)
+		logger.OnErr(r.countError)
+	}
+	return r
+}
+
+// countError pauses the input once it exceeds its error limit.
+func (r *RunningInput) countError() {
+	if !r.breaker.error() {
+		return
+	}
+	r.Paused.Set(1)
+	r.Pauses.Incr(1)
+	r.log.Warnf("More than %d errors within a minute, pausing for %s",
+		r.Config.ErrorLimit, r.Config.ErrorCooldown)
+}
+
+// IsPaused returns true if the input is paused after exceeding its error
+// limit, the input should not be gathered while paused.
+func (r *RunningInput) IsPaused() bool {
+	if r.breaker == nil {
+		return false
+	}
+
+	paused := r.breaker.paused()
+	if !paused && r.Paused.Get() == 1 {
+		r.Paused.Set(0)
+		r.log.Infof("Resuming after pause")
+	}
+	return paused
 }
 
 // InputConfig is the common config for all inputs.
@@ -59,6 +96,14 @@ type InputConfig struct {
 	Name     string
 	Alias    string
 	Interval time.Duration
+	// AliasTag adds the alias as the input_alias tag to all metrics.
+	AliasTag bool
+	// Route is set on all metrics of the input.
+	Route string
+	// ErrorLimit is the number of errors within a minute, after which the
+	// input is paused for ErrorCooldown.
+	ErrorLimit    int
+	ErrorCooldown time.Duration
 
 	NameOverride      string
 	MeasurementPrefix string
@@ -99,6 +144,14 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 		r.Config.Tags,
 		r.defaultTags)
 
+	if r.Config.AliasTag && r.Config.Alias != "" {
+		m.AddTag("input_alias", r.Config.Alias)
+	}
+
+	if r.Config.Route != "" {
+		m.SetRoute(r.Config.Route)
+	}
+
 	r.Config.Filter.Modify(metric)
 	if len(metric.FieldList()) == 0 {
 		r.metricFiltered(metric)
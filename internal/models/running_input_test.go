@@ -204,6 +204,58 @@ func TestMakeMetricNameOverride(t *testing.T) {
 	require.Equal(t, expected, m)
 }
 
+func TestMakeMetricRoute(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:  "TestRunningInput",
+		Route: "scripts",
+	})
+
+	m := testutil.MustMetric("RITest",
+		map[string]string{},
+		map[string]interface{}{
+			"value": int64(101),
+		},
+		time.Now())
+	m = ri.MakeMetric(m)
+	require.Equal(t, "scripts", m.Route())
+	require.Equal(t, "scripts", m.Copy().Route())
+}
+
+func TestMakeMetricAliasTag(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:     "TestRunningInput",
+		Alias:    "primary",
+		AliasTag: true,
+	})
+
+	m := testutil.MustMetric("RITest",
+		map[string]string{},
+		map[string]interface{}{
+			"value": int64(101),
+		},
+		time.Now())
+	m = ri.MakeMetric(m)
+	tag, ok := m.GetTag("input_alias")
+	require.True(t, ok)
+	require.Equal(t, "primary", tag)
+}
+
+func TestMakeMetricAliasNoTag(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:  "TestRunningInput",
+		Alias: "primary",
+	})
+
+	m := testutil.MustMetric("RITest",
+		map[string]string{},
+		map[string]interface{}{
+			"value": int64(101),
+		},
+		time.Now())
+	m = ri.MakeMetric(m)
+	require.False(t, m.HasTag("input_alias"))
+}
+
 func TestMakeMetricNamePrefix(t *testing.T) {
 	now := time.Now()
 	ri := NewRunningInput(&testInput{}, &InputConfig{
@@ -287,6 +339,43 @@ func TestMetricErrorCounters(t *testing.T) {
 	require.GreaterOrEqual(t, int64(1), GlobalGatherErrors.Get())
 }
 
+func TestRunningInput_ErrorLimit(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:          "TestRunningInput_ErrorLimit",
+		ErrorLimit:    2,
+		ErrorCooldown: time.Hour,
+	})
+	ri.Paused.Set(0)
+	ri.Pauses.Set(0)
+	require.False(t, ri.IsPaused())
+
+	ri.Log().Error("Oh no")
+	ri.Log().Error("Oh no")
+	require.False(t, ri.IsPaused())
+
+	ri.Log().Error("Oh no")
+	require.True(t, ri.IsPaused())
+	require.Equal(t, int64(1), ri.Paused.Get())
+	require.Equal(t, int64(1), ri.Pauses.Get())
+
+	// Resume once the cooldown is over.
+	ri.breaker.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	require.False(t, ri.IsPaused())
+	require.Equal(t, int64(0), ri.Paused.Get())
+	require.Equal(t, int64(1), ri.Pauses.Get())
+}
+
+func TestRunningInput_NoErrorLimit(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name: "TestRunningInput_NoErrorLimit",
+	})
+
+	for i := 0; i < 100; i++ {
+		ri.Log().Error("Oh no")
+	}
+	require.False(t, ri.IsPaused())
+}
+
 type testInput struct{}
 
 func (t *testInput) Description() string                   { return "" }
@@ -1,11 +1,13 @@
 package models
 
 import (
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	serializer "github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -15,6 +17,9 @@ const (
 
 	// Default number of metrics kept. It should be a multiple of batch size.
 	DEFAULT_METRIC_BUFFER_LIMIT = 10000
+
+	// Name of the route of metrics without a route.
+	DEFAULT_ROUTE = "default"
 )
 
 // OutputConfig containing name and filter
@@ -27,16 +32,30 @@ type OutputConfig struct {
 	FlushJitter       *time.Duration
 	MetricBufferLimit int
 	MetricBatchSize   int
+	// MetricBatchBytes triggers a write once the new metrics in the buffer
+	// are larger than this many bytes in line protocol.
+	MetricBatchBytes int64
+
+	// BufferDirectory enables the disk buffer, metrics that do not fit into
+	// the memory buffer or are unsent on shutdown are stored here.
+	BufferDirectory string
+	// BufferDiskLimit is the maximum size of the disk buffer in bytes.
+	BufferDiskLimit int64
 
 	NameOverride string
 	NamePrefix   string
 	NameSuffix   string
+
+	// AcceptRoutes lists the routes of the metrics written to the output, by
+	// default only metrics without a route are written.
+	AcceptRoutes []string
 }
 
 // RunningOutput contains the output configuration
 type RunningOutput struct {
 	// Must be 64-bit aligned
 	newMetricsCount int64
+	newMetricsSize  int64
 	droppedMetrics  int64
 
 	Output            telegraf.Output
@@ -50,9 +69,13 @@ type RunningOutput struct {
 	BatchReady chan time.Time
 
 	buffer *Buffer
+	disk   *DiskBuffer
 	log    telegraf.Logger
 
 	aggMutex sync.Mutex
+
+	sizeMutex      sync.Mutex
+	sizeSerializer *serializer.Serializer
 }
 
 func NewRunningOutput(
@@ -107,6 +130,11 @@ func NewRunningOutput(
 		log: logger,
 	}
 
+	if config.MetricBatchBytes > 0 {
+		ro.sizeSerializer = serializer.NewSerializer()
+		ro.sizeSerializer.SetFieldTypeSupport(serializer.UintSupport)
+	}
+
 	return ro
 }
 
@@ -127,13 +155,47 @@ func (r *RunningOutput) Init() error {
 		}
 
 	}
+
+	if r.Config.BufferDirectory != "" && r.disk == nil {
+		disk, err := NewDiskBuffer(r.Config.BufferDirectory,
+			r.Config.BufferDiskLimit, r.Config.Name, r.Config.Alias)
+		if err != nil {
+			return fmt.Errorf("could not open disk buffer: %v", err)
+		}
+		if n := disk.Len(); n > 0 {
+			r.log.Infof("Found %d metrics in disk buffer %s", n, r.Config.BufferDirectory)
+		}
+		r.disk = disk
+	}
 	return nil
 }
 
+// acceptsRoute returns true if metrics of the route are written to the output.
+// Metrics without a route are in the route named "default".
+func (ro *RunningOutput) acceptsRoute(route string) bool {
+	if route == "" {
+		route = DEFAULT_ROUTE
+	}
+	if len(ro.Config.AcceptRoutes) == 0 {
+		return route == DEFAULT_ROUTE
+	}
+	for _, r := range ro.Config.AcceptRoutes {
+		if r == route {
+			return true
+		}
+	}
+	return false
+}
+
 // AddMetric adds a metric to the output.
 //
 // Takes ownership of metric
 func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
+	if !ro.acceptsRoute(metric.Route()) {
+		ro.metricFiltered(metric)
+		return
+	}
+
 	if ok := ro.Config.Filter.Select(metric); !ok {
 		ro.metricFiltered(metric)
 		return
@@ -164,12 +226,24 @@ func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
 		metric.AddSuffix(ro.Config.NameSuffix)
 	}
 
+	// Once the memory buffer is full new metrics are spooled to disk instead
+	// of overwriting the oldest metrics.  They are counted as added as if
+	// they were added to the memory buffer.
+	if ro.disk != nil && ro.buffer.Len() >= ro.MetricBufferLimit {
+		ro.buffer.MetricsAdded.Incr(1)
+		ro.spool(metric)
+		return
+	}
+
 	dropped := ro.buffer.Add(metric)
 	atomic.AddInt64(&ro.droppedMetrics, int64(dropped))
 
 	count := atomic.AddInt64(&ro.newMetricsCount, 1)
-	if count == int64(ro.MetricBatchSize) {
+	size := ro.addMetricSize(metric)
+	if count == int64(ro.MetricBatchSize) ||
+		(ro.Config.MetricBatchBytes > 0 && size >= ro.Config.MetricBatchBytes) {
 		atomic.StoreInt64(&ro.newMetricsCount, 0)
+		atomic.StoreInt64(&ro.newMetricsSize, 0)
 		select {
 		case ro.BatchReady <- time.Now():
 		default:
@@ -177,6 +251,23 @@ func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
 	}
 }
 
+// addMetricSize adds the line protocol size of the metric to the size of the
+// new metrics and returns the total.  The size is only tracked when
+// MetricBatchBytes is set.
+func (ro *RunningOutput) addMetricSize(metric telegraf.Metric) int64 {
+	if ro.sizeSerializer == nil {
+		return 0
+	}
+
+	ro.sizeMutex.Lock()
+	octets, err := ro.sizeSerializer.Serialize(metric)
+	ro.sizeMutex.Unlock()
+	if err != nil {
+		return atomic.LoadInt64(&ro.newMetricsSize)
+	}
+	return atomic.AddInt64(&ro.newMetricsSize, int64(len(octets)))
+}
+
 // Write writes all metrics to the output, stopping when all have been sent on
 // or error.
 func (ro *RunningOutput) Write() error {
@@ -189,6 +280,7 @@ func (ro *RunningOutput) Write() error {
 	}
 
 	atomic.StoreInt64(&ro.newMetricsCount, 0)
+	atomic.StoreInt64(&ro.newMetricsSize, 0)
 
 	// Only process the metrics in the buffer now.  Metrics added while we are
 	// writing will be sent on the next call.
@@ -207,9 +299,44 @@ func (ro *RunningOutput) Write() error {
 		}
 		ro.buffer.Accept(batch)
 	}
+
+	if ro.disk != nil {
+		return ro.writeDisk()
+	}
 	return nil
 }
 
+// writeDisk sends the metrics stored in the disk buffer, oldest first.
+func (ro *RunningOutput) writeDisk() error {
+	for {
+		batch, err := ro.disk.Batch(ro.MetricBatchSize)
+		if err != nil {
+			return fmt.Errorf("reading disk buffer: %v", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err = ro.write(batch)
+		if err != nil {
+			ro.disk.Reject(batch)
+			return err
+		}
+		if err := ro.disk.Accept(batch); err != nil {
+			return fmt.Errorf("updating disk buffer: %v", err)
+		}
+	}
+}
+
+// spool adds metrics to the disk buffer.
+func (ro *RunningOutput) spool(metrics ...telegraf.Metric) {
+	dropped, err := ro.disk.Add(metrics...)
+	if err != nil {
+		ro.log.Errorf("Error writing to disk buffer: %v", err)
+	}
+	atomic.AddInt64(&ro.droppedMetrics, int64(dropped))
+}
+
 // WriteBatch writes a single batch of metrics to the output.
 func (ro *RunningOutput) WriteBatch() error {
 	batch := ro.buffer.Batch(ro.MetricBatchSize)
@@ -227,12 +354,31 @@ func (ro *RunningOutput) WriteBatch() error {
 	return nil
 }
 
-// Close closes the output
+// Close closes the output.  When the disk buffer is enabled, unsent metrics
+// are moved from the memory buffer to disk.
 func (r *RunningOutput) Close() {
 	err := r.Output.Close()
 	if err != nil {
 		r.log.Errorf("Error closing output: %v", err)
 	}
+
+	if r.disk == nil {
+		return
+	}
+
+	if n := r.buffer.Len(); n > 0 {
+		// Batch returns the newest metrics first.
+		batch := r.buffer.Batch(n)
+		for i, j := 0, len(batch)-1; i < j; i, j = i+1, j-1 {
+			batch[i], batch[j] = batch[j], batch[i]
+		}
+		r.spool(batch...)
+		r.log.Infof("Stored %d unsent metrics in disk buffer", n)
+	}
+
+	if err := r.disk.Close(); err != nil {
+		r.log.Errorf("Error closing disk buffer: %v", err)
+	}
 }
 
 func (r *RunningOutput) write(metrics []telegraf.Metric) error {
@@ -256,6 +402,9 @@ func (r *RunningOutput) write(metrics []telegraf.Metric) error {
 func (r *RunningOutput) LogBufferStatus() {
 	nBuffer := r.buffer.Len()
 	r.log.Debugf("Buffer fullness: %d / %d metrics", nBuffer, r.MetricBufferLimit)
+	if r.disk != nil {
+		r.log.Debugf("Disk buffer: %d metrics", r.disk.Len())
+	}
 }
 
 func (r *RunningOutput) Log() telegraf.Logger {
@@ -2,6 +2,8 @@ package models
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"sync"
 	"testing"
 	"time"
@@ -109,6 +111,54 @@ func TestRunningOutput_DropFilter(t *testing.T) {
 	assert.Len(t, m.Metrics(), 8)
 }
 
+func TestRunningOutput_AcceptRoutes(t *testing.T) {
+	routed := func(route string) telegraf.Metric {
+		m := testutil.TestMetric(101, "metric_"+route)
+		m.SetRoute(route)
+		return m
+	}
+
+	tests := []struct {
+		name         string
+		acceptRoutes []string
+		expected     []string
+	}{
+		{
+			name:     "default only",
+			expected: []string{"metric_", "metric_default"},
+		},
+		{
+			name:         "listed routes",
+			acceptRoutes: []string{"scripts"},
+			expected:     []string{"metric_scripts"},
+		},
+		{
+			name:         "listed routes and default",
+			acceptRoutes: []string{"scripts", "default"},
+			expected:     []string{"metric_", "metric_default", "metric_scripts"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockOutput{}
+			ro := NewRunningOutput("test", m, &OutputConfig{
+				AcceptRoutes: tt.acceptRoutes,
+			}, 1000, 10000)
+
+			for _, route := range []string{"", "default", "scripts", "other"} {
+				ro.AddMetric(routed(route))
+			}
+			require.NoError(t, ro.Write())
+
+			var names []string
+			for _, metric := range m.Metrics() {
+				names = append(names, metric.Name())
+			}
+			require.ElementsMatch(t, tt.expected, names)
+		})
+	}
+}
+
 // Test that NameDrop filters without a match do nothing.
 func TestRunningOutput_PassFilter(t *testing.T) {
 	conf := &OutputConfig{
@@ -512,6 +562,113 @@ func TestInternalMetrics(t *testing.T) {
 	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
 }
 
+// Verify that a batch is ready once the new metrics exceed MetricBatchBytes.
+func TestRunningOutputMetricBatchBytes(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:           Filter{},
+		MetricBatchBytes: 100,
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	// Each metric is 51 bytes in line protocol.
+	ro.AddMetric(testutil.TestMetric(101, "metric1"))
+	select {
+	case <-ro.BatchReady:
+		require.Fail(t, "batch ready before reaching the size")
+	default:
+	}
+
+	ro.AddMetric(testutil.TestMetric(101, "metric2"))
+	ro.AddMetric(testutil.TestMetric(101, "metric3"))
+	select {
+	case <-ro.BatchReady:
+	default:
+		require.Fail(t, "batch not ready after reaching the size")
+	}
+
+	err := ro.WriteBatch()
+	require.NoError(t, err)
+	require.Len(t, m.Metrics(), 3)
+}
+
+// Verify that metrics are spooled to disk once the memory buffer is full and
+// sent after the memory buffer.
+func TestRunningOutputDiskBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "running_output")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := &OutputConfig{
+		Filter:          Filter{},
+		BufferDirectory: dir,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("disktest", m, conf, 2, 4)
+	require.NoError(t, ro.Init())
+	ro.buffer.MetricsAdded.Set(0)
+	ro.buffer.MetricsWritten.Set(0)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+	require.Equal(t, 4, ro.buffer.Len())
+	require.Equal(t, 6, ro.disk.Len())
+	require.Equal(t, int64(10), ro.buffer.MetricsAdded.Get())
+
+	err = ro.Write()
+	require.Error(t, err)
+	require.Len(t, m.Metrics(), 0)
+
+	m.failWrite = false
+	err = ro.Write()
+	require.NoError(t, err)
+
+	expected := append(append([]telegraf.Metric{}, first5...), next5...)
+	testutil.RequireMetricsEqual(t, expected, m.Metrics(), testutil.SortMetrics())
+	require.Equal(t, 0, ro.disk.Len())
+	require.Equal(t, ro.buffer.MetricsAdded.Get(), ro.buffer.MetricsWritten.Get())
+	ro.Close()
+}
+
+// Verify that unsent metrics are stored on close and sent after a restart.
+func TestRunningOutputDiskBufferClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "running_output")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := &OutputConfig{
+		Filter:          Filter{},
+		BufferDirectory: dir,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("disktest", m, conf, 2, 10)
+	require.NoError(t, ro.Init())
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	ro.Close()
+
+	m = &mockOutput{}
+	ro = NewRunningOutput("disktest", m, conf, 2, 10)
+	require.NoError(t, ro.Init())
+	require.Equal(t, 5, ro.disk.Len())
+
+	err = ro.Write()
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, first5, m.Metrics())
+	ro.Close()
+}
+
 type mockOutput struct {
 	sync.Mutex
 
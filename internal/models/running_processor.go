@@ -2,6 +2,7 @@ package models
 
 import (
 	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/selfstat"
@@ -12,6 +13,10 @@ type RunningProcessor struct {
 	log       telegraf.Logger
 	Processor telegraf.Processor
 	Config    *ProcessorConfig
+
+	MetricsProcessed selfstat.Stat
+	MetricsFiltered  selfstat.Stat
+	ProcessTime      selfstat.Stat
 }
 
 type RunningProcessors []*RunningProcessor
@@ -44,14 +49,38 @@ func NewRunningProcessor(processor telegraf.Processor, config *ProcessorConfig)
 	return &RunningProcessor{
 		Processor: processor,
 		Config:    config,
-		log:       logger,
+		MetricsProcessed: selfstat.Register(
+			"process",
+			"metrics_processed",
+			tags,
+		),
+		MetricsFiltered: selfstat.Register(
+			"process",
+			"metrics_filtered",
+			tags,
+		),
+		ProcessTime: selfstat.RegisterTiming(
+			"process",
+			"process_time_ns",
+			tags,
+		),
+		log: logger,
 	}
 }
 
 func (rp *RunningProcessor) metricFiltered(metric telegraf.Metric) {
+	incr(rp.MetricsFiltered, 1)
 	metric.Drop()
 }
 
+// incr increments the stat, which is not registered if the processor was not
+// created with NewRunningProcessor.
+func incr(stat selfstat.Stat, v int64) {
+	if stat != nil {
+		stat.Incr(v)
+	}
+}
+
 func containsMetric(item telegraf.Metric, metrics []telegraf.Metric) bool {
 	for _, m := range metrics {
 		if item == m {
@@ -75,6 +104,11 @@ func (rp *RunningProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
 	rp.Lock()
 	defer rp.Unlock()
 
+	start := time.Now()
+	defer func() {
+		incr(rp.ProcessTime, time.Since(start).Nanoseconds())
+	}()
+
 	ret := []telegraf.Metric{}
 
 	for _, metric := range in {
@@ -93,12 +127,22 @@ func (rp *RunningProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
 
 		// This metric should pass through the filter, so call the filter Apply
 		// function and append results to the output slice.
+		incr(rp.MetricsProcessed, 1)
 		ret = append(ret, rp.Processor.Apply(metric)...)
 	}
 
 	return ret
 }
 
+// Close releases the resources of the processor, if it holds any.
+func (rp *RunningProcessor) Close() {
+	if p, ok := rp.Processor.(telegraf.ProcessorCloser); ok {
+		if err := p.Close(); err != nil && rp.log != nil {
+			rp.log.Errorf("Error closing processor: %v", err)
+		}
+	}
+}
+
 func (r *RunningProcessor) Log() telegraf.Logger {
 	return r.log
 }
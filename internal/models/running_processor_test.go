@@ -164,6 +164,37 @@ func TestRunningProcessor_Apply(t *testing.T) {
 	}
 }
 
+func TestRunningProcessor_InternalMetrics(t *testing.T) {
+	rp := NewRunningProcessor(TagProcessor("apply", "true"), &ProcessorConfig{
+		Name: "stats",
+		Filter: Filter{
+			NamePass:  []string{"cpu"},
+			FieldDrop: []string{"value"},
+		},
+	})
+	rp.MetricsProcessed.Set(0)
+	rp.MetricsFiltered.Set(0)
+	require.NoError(t, rp.Config.Filter.Compile())
+
+	rp.Apply(
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": 42.0, "idle": 1.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("mem",
+			map[string]string{},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0)),
+	)
+
+	require.Equal(t, int64(1), rp.MetricsProcessed.Get())
+	require.Equal(t, int64(1), rp.MetricsFiltered.Get())
+}
+
 func TestRunningProcessor_Order(t *testing.T) {
 	rp1 := &RunningProcessor{
 		Config: &ProcessorConfig{
@@ -187,3 +218,23 @@ func TestRunningProcessor_Order(t *testing.T) {
 		RunningProcessors{rp1, rp2, rp3},
 		procs)
 }
+
+type closingProcessor struct {
+	MockProcessor
+	closed bool
+}
+
+func (p *closingProcessor) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestRunningProcessor_Close(t *testing.T) {
+	processor := &closingProcessor{}
+	rp := NewRunningProcessor(processor, &ProcessorConfig{Name: "TestRunningProcessor_Close"})
+	rp.Close()
+	require.True(t, processor.closed)
+
+	// Processors without Close are ignored.
+	NewRunningProcessor(TagProcessor("a", "b"), &ProcessorConfig{Name: "test"}).Close()
+}
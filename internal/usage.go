@@ -16,10 +16,17 @@ The commands & flags are:
   --aggregator-filter <filter>   filter the aggregators to enable, separator is :
   --config <file>                configuration file to load
   --config-directory <directory> directory containing additional *.conf files
+  --config-key-file <file>       file containing the key of encrypted configuration
+                                 values, defaults to $TELEGRAF_CONFIG_KEY_FILE
+  --config-url-watch-interval <duration>
+                                 how often to check a config loaded from a URL for
+                                 changes and reload it, 0 disables the check
   --plugin-directory             directory containing *.so files, this directory will be
                                  searched recursively. Any Plugin found will be loaded
                                  and namespaced.
   --debug                        turn on debug logging
+  --encrypt                      encrypt the value read from stdin with the key of
+                                 --config-key-file, for use in the configuration
   --input-filter <filter>        filter the inputs to enable, separator is :
   --input-list                   print available input plugins.
   --output-filter <filter>       filter the outputs to enable, separator is :
@@ -32,6 +39,8 @@ The commands & flags are:
                                  Valid values are 'agent', 'global_tags', 'outputs',
                                  'processors', 'aggregators' and 'inputs'
   --sample-config                print out full sample configuration
+  --schema                       print the configuration schema of the plugins
+                                 as JSON, filters limit the plugins included
   --test                         gather metrics, print them out, and exit;
                                  processors, aggregators, and outputs are not run
   --test-wait                    wait up to this many seconds for service
@@ -47,6 +56,12 @@ Examples:
   # generate config with only cpu input & influxdb output plugins defined
   telegraf --input-filter cpu --output-filter influxdb config
 
+  # print the configuration schema of the exec input as JSON
+  telegraf --section-filter inputs --input-filter exec --schema
+
+  # encrypt a password for use in the configuration
+  telegraf --config-key-file /etc/telegraf/key --encrypt < password.txt
+
   # run a single telegraf collection, outputing metrics to stdout
   telegraf --config telegraf.conf --test
 
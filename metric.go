@@ -76,4 +76,8 @@ type Metric interface {
 	// Mark Metric as an aggregate
 	SetAggregate(bool)
 	IsAggregate() bool
+
+	// Route of the metric, it is only written to outputs accepting the route.
+	SetRoute(route string)
+	Route() string
 }
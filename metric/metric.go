@@ -17,6 +17,7 @@ type metric struct {
 
 	tp        telegraf.ValueType
 	aggregate bool
+	route     string
 }
 
 func New(
@@ -74,6 +75,7 @@ func FromMetric(other telegraf.Metric) telegraf.Metric {
 		tm:        other.Time(),
 		tp:        other.Type(),
 		aggregate: other.IsAggregate(),
+		route:     other.Route(),
 	}
 
 	for i, tag := range other.TagList() {
@@ -239,6 +241,7 @@ func (m *metric) Copy() telegraf.Metric {
 		tm:        m.tm,
 		tp:        m.tp,
 		aggregate: m.aggregate,
+		route:     m.route,
 	}
 
 	for i, tag := range m.tags {
@@ -259,6 +262,14 @@ func (m *metric) IsAggregate() bool {
 	return m.aggregate
 }
 
+func (m *metric) SetRoute(route string) {
+	m.route = route
+}
+
+func (m *metric) Route() string {
+	return m.route
+}
+
 func (m *metric) HashID() uint64 {
 	h := fnv.New64a()
 	h.Write([]byte(m.name))
@@ -272,6 +283,27 @@ func (m *metric) HashID() uint64 {
 	return h.Sum64()
 }
 
+// HashID returns the hash of the series with the name and tags, equal to the
+// HashID of a metric with them.
+func HashID(name string, tags map[string]string) uint64 {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	h.Write([]byte("\n"))
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte("\n"))
+		h.Write([]byte(tags[k]))
+		h.Write([]byte("\n"))
+	}
+	return h.Sum64()
+}
+
 func (m *metric) Accept() {
 }
 
@@ -240,6 +240,18 @@ func TestHashID(t *testing.T) {
 	assert.NotEqual(t, hash, m.HashID())
 }
 
+func TestHashIDOfSeries(t *testing.T) {
+	tags := map[string]string{
+		"datacenter": "us-east-1",
+		"mytag":      "foo",
+		"another":    "tag",
+	}
+	m, _ := New("cpu", tags, map[string]interface{}{"value": float64(1)}, time.Now())
+	assert.Equal(t, m.HashID(), HashID("cpu", tags))
+	assert.NotEqual(t, m.HashID(), HashID("mem", tags))
+	assert.NotEqual(t, m.HashID(), HashID("cpu", nil))
+}
+
 func TestHashID_Consistency(t *testing.T) {
 	m, _ := New(
 		"cpu",
@@ -0,0 +1,137 @@
+package deadman
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+var sampleConfig = `
+  ## The period on which to check for missing series.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Number of periods without a metric until a series is reported missing.
+  # periods = 3
+
+  ## Tags identifying a series, by default all tags are used.
+  # group_by = ["port", "command"]
+
+  ## Time after which a missing series is no longer reported and forgotten,
+  ## set to "0s" to report missing series forever.
+  # series_timeout = "24h"
+`
+
+type Deadman struct {
+	Periods       int               `toml:"periods"`
+	GroupBy       []string          `toml:"group_by"`
+	SeriesTimeout internal.Duration `toml:"series_timeout"`
+
+	// The series that have reported, including those that are missing.
+	series map[uint64]*series
+}
+
+type series struct {
+	name     string
+	tags     map[string]string
+	lastSeen time.Time
+	// Whether a metric was added in the current period.
+	seen bool
+	// Number of periods pushed without a metric of the series.
+	missed int
+}
+
+func NewDeadman() *Deadman {
+	return &Deadman{
+		Periods:       3,
+		SeriesTimeout: internal.Duration{Duration: 24 * time.Hour},
+		series:        make(map[uint64]*series),
+	}
+}
+
+func (d *Deadman) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Deadman) Description() string {
+	return "Report series that stopped sending metrics."
+}
+
+func (d *Deadman) Init() error {
+	if d.Periods < 1 {
+		return fmt.Errorf("periods must be at least 1")
+	}
+	return nil
+}
+
+func (d *Deadman) Add(in telegraf.Metric) {
+	tags := in.Tags()
+	if len(d.GroupBy) > 0 {
+		tags = make(map[string]string, len(d.GroupBy))
+		for _, key := range d.GroupBy {
+			if value, ok := in.GetTag(key); ok {
+				tags[key] = value
+			}
+		}
+	}
+
+	id := metric.HashID(in.Name(), tags)
+	s, ok := d.series[id]
+	if !ok {
+		s = &series{
+			name: in.Name(),
+			tags: tags,
+		}
+		d.series[id] = s
+	}
+	s.lastSeen = time.Now()
+	s.seen = true
+	s.missed = 0
+}
+
+func (d *Deadman) Push(acc telegraf.Accumulator) {
+	for id, s := range d.series {
+		if s.seen {
+			s.seen = false
+			continue
+		}
+
+		s.missed++
+		if s.missed < d.Periods {
+			continue
+		}
+
+		if d.SeriesTimeout.Duration > 0 && time.Since(s.lastSeen) > d.SeriesTimeout.Duration {
+			delete(d.series, id)
+			continue
+		}
+
+		tags := make(map[string]string, len(s.tags)+1)
+		for k, v := range s.tags {
+			tags[k] = v
+		}
+		tags["measurement"] = s.name
+
+		fields := map[string]interface{}{
+			"missing_periods": int64(s.missed),
+			"last_seen":       s.lastSeen.UnixNano(),
+		}
+		acc.AddFields("missing_data", fields, tags)
+	}
+}
+
+// Reset does nothing, series are tracked across periods.
+func (d *Deadman) Reset() {
+}
+
+func init() {
+	aggregators.Add("deadman", func() telegraf.Aggregator {
+		return NewDeadman()
+	})
+}
@@ -0,0 +1,139 @@
+package deadman
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(tags map[string]string) telegraf.Metric {
+	return testutil.MustMetric("exec",
+		tags,
+		map[string]interface{}{"value": 42},
+		time.Now())
+}
+
+func missing(tags map[string]string, periods int64) telegraf.Metric {
+	return testutil.MustMetric("missing_data",
+		tags,
+		map[string]interface{}{"missing_periods": periods},
+		time.Unix(0, 0))
+}
+
+// withoutLastSeen removes the last_seen field, which depends on the time the
+// metrics were added.
+func withoutLastSeen(metrics []telegraf.Metric) []telegraf.Metric {
+	for _, m := range metrics {
+		m.RemoveField("last_seen")
+	}
+	return metrics
+}
+
+func TestDeadmanMissing(t *testing.T) {
+	acc := testutil.Accumulator{}
+	d := NewDeadman()
+	d.Periods = 2
+	require.NoError(t, d.Init())
+
+	d.Add(newMetric(map[string]string{"port": "8080"}))
+	d.Add(newMetric(map[string]string{"port": "9090"}))
+	d.Push(&acc)
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	// The first missed period is tolerated.
+	d.Add(newMetric(map[string]string{"port": "9090"}))
+	d.Push(&acc)
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	d.Push(&acc)
+	d.Push(&acc)
+
+	expected := []telegraf.Metric{
+		missing(map[string]string{"measurement": "exec", "port": "8080"}, 2),
+		missing(map[string]string{"measurement": "exec", "port": "9090"}, 2),
+		missing(map[string]string{"measurement": "exec", "port": "8080"}, 3),
+	}
+	testutil.RequireMetricsEqual(t, expected, withoutLastSeen(acc.GetTelegrafMetrics()),
+		testutil.IgnoreTime(), testutil.SortMetrics())
+
+	// A series that reports again is no longer missing.
+	acc.ClearMetrics()
+	d.Add(newMetric(map[string]string{"port": "8080"}))
+	d.Push(&acc)
+	d.Push(&acc)
+
+	expected = []telegraf.Metric{
+		missing(map[string]string{"measurement": "exec", "port": "9090"}, 3),
+		missing(map[string]string{"measurement": "exec", "port": "9090"}, 4),
+	}
+	testutil.RequireMetricsEqual(t, expected, withoutLastSeen(acc.GetTelegrafMetrics()),
+		testutil.IgnoreTime())
+}
+
+func TestDeadmanGroupBy(t *testing.T) {
+	acc := testutil.Accumulator{}
+	d := NewDeadman()
+	d.Periods = 1
+	d.GroupBy = []string{"port"}
+	require.NoError(t, d.Init())
+
+	d.Add(newMetric(map[string]string{"port": "8080", "pid": "1"}))
+	d.Push(&acc)
+
+	// A new process on the same port keeps the series alive.
+	d.Add(newMetric(map[string]string{"port": "8080", "pid": "2"}))
+	d.Push(&acc)
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	d.Push(&acc)
+
+	expected := []telegraf.Metric{
+		missing(map[string]string{"measurement": "exec", "port": "8080"}, 1),
+	}
+	testutil.RequireMetricsEqual(t, expected, withoutLastSeen(acc.GetTelegrafMetrics()),
+		testutil.IgnoreTime())
+}
+
+func TestDeadmanLastSeen(t *testing.T) {
+	acc := testutil.Accumulator{}
+	d := NewDeadman()
+	d.Periods = 1
+	require.NoError(t, d.Init())
+
+	before := time.Now()
+	d.Add(newMetric(nil))
+	d.Push(&acc)
+	d.Push(&acc)
+
+	require.Len(t, acc.Metrics, 1)
+	lastSeen, ok := acc.Metrics[0].Fields["last_seen"].(int64)
+	require.True(t, ok)
+	require.True(t, lastSeen >= before.UnixNano())
+	require.True(t, lastSeen <= time.Now().UnixNano())
+}
+
+func TestDeadmanSeriesTimeout(t *testing.T) {
+	acc := testutil.Accumulator{}
+	d := NewDeadman()
+	d.Periods = 1
+	require.NoError(t, d.Init())
+
+	d.Add(newMetric(map[string]string{"port": "8080"}))
+	d.Push(&acc)
+	for _, s := range d.series {
+		s.lastSeen = time.Now().Add(-25 * time.Hour)
+	}
+	d.Push(&acc)
+
+	require.Empty(t, acc.GetTelegrafMetrics())
+	require.Empty(t, d.series)
+}
+
+func TestDeadmanInitErrors(t *testing.T) {
+	d := NewDeadman()
+	d.Periods = 0
+	require.Error(t, d.Init())
+}
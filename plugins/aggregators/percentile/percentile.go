@@ -0,0 +1,152 @@
+package percentile
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Percentiles to compute, between 0 and 100.
+  # percentiles = [50.0, 90.0, 99.0]
+
+  ## Tags to group by, other tags are removed from the aggregates.  By default
+  ## metrics are grouped by all of their tags.
+  # group_by = ["port", "command"]
+`
+
+type Percentile struct {
+	Percentiles []float64 `toml:"percentiles"`
+	GroupBy     []string  `toml:"group_by"`
+
+	suffixes []string
+	cache    map[uint64]*aggregate
+}
+
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string][]float64
+}
+
+func NewPercentile() *Percentile {
+	p := &Percentile{
+		Percentiles: []float64{50, 90, 99},
+	}
+	p.Reset()
+	return p
+}
+
+func (p *Percentile) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Percentile) Description() string {
+	return "Compute percentiles of each numeric field over the period."
+}
+
+func (p *Percentile) Init() error {
+	if len(p.Percentiles) == 0 {
+		return fmt.Errorf("percentiles must not be empty")
+	}
+
+	p.suffixes = make([]string, 0, len(p.Percentiles))
+	for _, pct := range p.Percentiles {
+		if pct < 0 || pct > 100 || math.IsNaN(pct) {
+			return fmt.Errorf("percentile %v is not between 0 and 100", pct)
+		}
+		p.suffixes = append(p.suffixes, suffix(pct))
+	}
+	return nil
+}
+
+// suffix returns the field suffix of a percentile, such as "_p50" or
+// "_p99_9".
+func suffix(pct float64) string {
+	s := strconv.FormatFloat(pct, 'f', -1, 64)
+	return "_p" + strings.Replace(s, ".", "_", 1)
+}
+
+func (p *Percentile) Add(in telegraf.Metric) {
+	tags := in.Tags()
+	if len(p.GroupBy) > 0 {
+		tags = make(map[string]string, len(p.GroupBy))
+		for _, key := range p.GroupBy {
+			if value, ok := in.GetTag(key); ok {
+				tags[key] = value
+			}
+		}
+	}
+
+	id := metric.HashID(in.Name(), tags)
+	a, ok := p.cache[id]
+	if !ok {
+		a = &aggregate{
+			name:   in.Name(),
+			tags:   tags,
+			fields: make(map[string][]float64),
+		}
+		p.cache[id] = a
+	}
+
+	for _, field := range in.FieldList() {
+		if fv, ok := internal.ToFloat64(field.Value); ok {
+			a.fields[field.Key] = append(a.fields[field.Key], fv)
+		}
+	}
+}
+
+func (p *Percentile) Push(acc telegraf.Accumulator) {
+	for _, a := range p.cache {
+		fields := make(map[string]interface{})
+		for k, values := range a.fields {
+			sort.Float64s(values)
+			for i, pct := range p.Percentiles {
+				fields[k+p.suffixes[i]] = percentile(values, pct)
+			}
+		}
+		if len(fields) > 0 {
+			acc.AddFields(a.name, fields, a.tags)
+		}
+	}
+}
+
+func (p *Percentile) Reset() {
+	p.cache = make(map[uint64]*aggregate)
+}
+
+// percentile interpolates linearly between the closest ranks of the sorted
+// values.
+func percentile(sorted []float64, pct float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+
+	rank := pct / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+func init() {
+	aggregators.Add("percentile", func() telegraf.Aggregator {
+		return NewPercentile()
+	})
+}
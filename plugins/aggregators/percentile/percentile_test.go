@@ -0,0 +1,130 @@
+package percentile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(tags map[string]string, fields map[string]interface{}) telegraf.Metric {
+	return testutil.MustMetric("exec", tags, fields, time.Unix(0, 0))
+}
+
+func TestPercentileDefault(t *testing.T) {
+	acc := testutil.Accumulator{}
+	p := NewPercentile()
+	require.NoError(t, p.Init())
+
+	for i := 1; i <= 11; i++ {
+		p.Add(newMetric(
+			map[string]string{"port": "8080"},
+			map[string]interface{}{
+				"latency": float64(i),
+				"count":   int64(i * 10),
+				"status":  "ok",
+			}))
+	}
+	p.Push(&acc)
+
+	expected := []telegraf.Metric{
+		newMetric(
+			map[string]string{"port": "8080"},
+			map[string]interface{}{
+				"latency_p50": float64(6),
+				"latency_p90": float64(10),
+				"latency_p99": float64(10.9),
+				"count_p50":   float64(60),
+				"count_p90":   float64(100),
+				"count_p99":   float64(109),
+			}),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(),
+		testutil.IgnoreTime())
+}
+
+func TestPercentileInterpolation(t *testing.T) {
+	acc := testutil.Accumulator{}
+	p := NewPercentile()
+	p.Percentiles = []float64{0, 25, 62.5, 100}
+	require.NoError(t, p.Init())
+
+	for _, v := range []float64{4, 1, 3, 2} {
+		p.Add(newMetric(nil, map[string]interface{}{"latency": v}))
+	}
+	p.Push(&acc)
+
+	expected := []telegraf.Metric{
+		newMetric(nil, map[string]interface{}{
+			"latency_p0":    float64(1),
+			"latency_p25":   float64(1.75),
+			"latency_p62_5": float64(2.875),
+			"latency_p100":  float64(4),
+		}),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(),
+		testutil.IgnoreTime())
+}
+
+func TestPercentileGroupBy(t *testing.T) {
+	acc := testutil.Accumulator{}
+	p := NewPercentile()
+	p.Percentiles = []float64{50}
+	p.GroupBy = []string{"port"}
+	require.NoError(t, p.Init())
+
+	p.Add(newMetric(
+		map[string]string{"port": "8080", "pid": "1"},
+		map[string]interface{}{"latency": float64(1)}))
+	p.Add(newMetric(
+		map[string]string{"port": "8080", "pid": "2"},
+		map[string]interface{}{"latency": float64(3)}))
+	p.Add(newMetric(
+		map[string]string{"port": "9090", "pid": "3"},
+		map[string]interface{}{"latency": float64(5)}))
+	p.Add(newMetric(
+		map[string]string{"pid": "4"},
+		map[string]interface{}{"latency": float64(7)}))
+	p.Push(&acc)
+
+	expected := []telegraf.Metric{
+		newMetric(
+			map[string]string{"port": "8080"},
+			map[string]interface{}{"latency_p50": float64(2)}),
+		newMetric(
+			map[string]string{"port": "9090"},
+			map[string]interface{}{"latency_p50": float64(5)}),
+		newMetric(
+			map[string]string{},
+			map[string]interface{}{"latency_p50": float64(7)}),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(),
+		testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestPercentileReset(t *testing.T) {
+	acc := testutil.Accumulator{}
+	p := NewPercentile()
+	require.NoError(t, p.Init())
+
+	p.Add(newMetric(nil, map[string]interface{}{"latency": float64(1)}))
+	p.Reset()
+	p.Add(newMetric(nil, map[string]interface{}{"status": "ok"}))
+	p.Push(&acc)
+
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestPercentileInitErrors(t *testing.T) {
+	p := NewPercentile()
+	p.Percentiles = []float64{}
+	require.Error(t, p.Init())
+
+	p.Percentiles = []float64{50, 101}
+	require.Error(t, p.Init())
+
+	p.Percentiles = []float64{-1}
+	require.Error(t, p.Init())
+}
@@ -0,0 +1,209 @@
+// Package pathparser implements the parsers creating metrics from documents
+// using path expressions, such as the jsonpath and xml data formats.
+package pathparser
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+var (
+	ErrNoMetric = fmt.Errorf("no metric in line")
+)
+
+// Path selects values of a document.
+type Path interface {
+	// Select returns the values matched from the current value.
+	Select(root, current interface{}) []interface{}
+	String() string
+}
+
+// Format is a document format and its path expressions.
+type Format struct {
+	// Prefix of the options in errors, ie "jsonpath".
+	Name string
+	// Query used when none is configured.
+	DefaultQuery string
+	// Compile parses a path expression.
+	Compile func(expr string) (Path, error)
+	// Parse returns the root of the document.
+	Parse func(buf []byte) (interface{}, error)
+	// Tag converts a selected value to a tag value, false to skip it.
+	Tag func(v interface{}) (string, bool)
+	// Field converts a selected value to a field value, false to skip it.
+	Field func(v interface{}) (interface{}, bool)
+}
+
+type Config struct {
+	MetricName  string
+	Query       string
+	Fields      map[string]string
+	Tags        map[string]string
+	DefaultTags map[string]string
+}
+
+// Parser creates one metric for every value of a document selected by the
+// query, with the fields and tags selected relative to it.
+type Parser struct {
+	format      *Format
+	metricName  string
+	query       Path
+	fields      map[string]Path
+	tags        map[string]Path
+	defaultTags map[string]string
+	timeFunc    func() time.Time
+}
+
+func New(format *Format, config *Config) (*Parser, error) {
+	if len(config.Fields) == 0 {
+		return nil, fmt.Errorf("%s_fields must contain at least one field", format.Name)
+	}
+
+	query := config.Query
+	if query == "" {
+		query = format.DefaultQuery
+	}
+	queryPath, err := format.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("%s_query: %v", format.Name, err)
+	}
+
+	fields, err := compileAll(format, config.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("%s_fields: %v", format.Name, err)
+	}
+
+	tags, err := compileAll(format, config.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("%s_tags: %v", format.Name, err)
+	}
+
+	return &Parser{
+		format:      format,
+		metricName:  config.MetricName,
+		query:       queryPath,
+		fields:      fields,
+		tags:        tags,
+		defaultTags: config.DefaultTags,
+		timeFunc:    time.Now,
+	}, nil
+}
+
+func compileAll(format *Format, exprs map[string]string) (map[string]Path, error) {
+	paths := make(map[string]Path, len(exprs))
+	for name, expr := range exprs {
+		path, err := format.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		paths[name] = path
+	}
+	return paths, nil
+}
+
+// Parse creates one metric for every value selected by the query.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	root, err := p.format.Parse(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	now := p.timeFunc()
+	metrics := make([]telegraf.Metric, 0)
+	for _, element := range p.query.Select(root, root) {
+		m, err := p.parseElement(root, element, now)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
+}
+
+func (p *Parser) parseElement(root, element interface{}, now time.Time) (telegraf.Metric, error) {
+	tags := make(map[string]string)
+	for k, v := range p.defaultTags {
+		tags[k] = v
+	}
+	for _, name := range sortedKeys(p.tags) {
+		v, ok, err := selectOne(p.tags[name], root, element)
+		if err != nil {
+			return nil, fmt.Errorf("tag %q: %v", name, err)
+		}
+		if !ok {
+			continue
+		}
+		if tag, ok := p.format.Tag(v); ok {
+			tags[name] = tag
+		}
+	}
+
+	fields := make(map[string]interface{})
+	for _, name := range sortedKeys(p.fields) {
+		v, ok, err := selectOne(p.fields[name], root, element)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", name, err)
+		}
+		if !ok {
+			continue
+		}
+		if field, ok := p.format.Field(v); ok {
+			fields[name] = field
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return metric.New(p.metricName, tags, fields, now)
+}
+
+// selectOne evaluates a field or tag path, which must resolve to at most one
+// value.
+func selectOne(path Path, root, element interface{}) (interface{}, bool, error) {
+	values := path.Select(root, element)
+	switch len(values) {
+	case 0:
+		return nil, false, nil
+	case 1:
+		return values[0], true, nil
+	default:
+		return nil, false, fmt.Errorf("path %q matched %d values, expected one", path, len(values))
+	}
+}
+
+func sortedKeys(m map[string]Path) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, ErrNoMetric
+	}
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.defaultTags = tags
+}
+
+// SetTimeFunc sets the function returning the time of the metrics.
+func (p *Parser) SetTimeFunc(fn func() time.Time) {
+	p.timeFunc = fn
+}
@@ -0,0 +1,199 @@
+// Package shim runs telegraf input plugins as a standalone program that can
+// be started by the execd input.  Metrics are written to stdout in line
+// protocol and logs to stderr, a gather is triggered by a newline on stdin,
+// a SIGHUP, SIGUSR1 or SIGUSR2 signal or the poll interval.
+package shim
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/agent"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+// PollIntervalDisabled disables gathering on an interval, the inputs are only
+// gathered when signaled.
+const PollIntervalDisabled = time.Duration(0)
+
+// Shim runs input plugins and writes their metrics to stdout.
+type Shim struct {
+	inputs []*models.RunningInput
+
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+// New creates a shim reading from stdin and writing to stdout.
+func New() *Shim {
+	return &Shim{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+	}
+}
+
+// AddInput adds an input plugin to the shim and initializes it.  The name is
+// used in the logs of the plugin.
+func (s *Shim) AddInput(name string, input telegraf.Input) error {
+	ri := models.NewRunningInput(input, &models.InputConfig{Name: name})
+	if err := ri.Init(); err != nil {
+		return fmt.Errorf("could not initialize input %s: %v", ri.LogName(), err)
+	}
+	s.inputs = append(s.inputs, ri)
+	return nil
+}
+
+// LoadConfig adds the inputs in the telegraf config file at path to the shim.
+// The inputs must be registered by the program, usually by importing them.
+func (s *Shim) LoadConfig(path string) error {
+	if path == "" {
+		return errors.New("no config file given")
+	}
+
+	c := config.NewConfig()
+	if err := c.LoadConfig(path); err != nil {
+		return err
+	}
+	for _, ri := range c.Inputs {
+		if err := ri.Init(); err != nil {
+			return fmt.Errorf("could not initialize input %s: %v", ri.LogName(), err)
+		}
+		s.inputs = append(s.inputs, ri)
+	}
+	return nil
+}
+
+// Run starts the inputs and gathers them until stdin is closed or the
+// program is interrupted.  Service inputs are started once and write their
+// metrics as they arrive.
+func (s *Shim) Run(pollInterval time.Duration) error {
+	if len(s.inputs) == 0 {
+		return errors.New("no inputs added")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	collect := make(chan os.Signal, 1)
+	listenForCollectMetricsSignals(collect)
+	defer signal.Stop(collect)
+
+	metricCh := make(chan telegraf.Metric, 100)
+	written := make(chan error, 1)
+	go func() {
+		written <- s.writeMetrics(metricCh)
+	}()
+
+	accs := make([]telegraf.Accumulator, 0, len(s.inputs))
+	var services []telegraf.ServiceInput
+	for _, ri := range s.inputs {
+		acc := agent.NewAccumulator(ri, metricCh)
+		accs = append(accs, acc)
+
+		if si, ok := ri.Input.(telegraf.ServiceInput); ok {
+			if err := si.Start(acc); err != nil {
+				stopServices(services)
+				close(metricCh)
+				<-written
+				return fmt.Errorf("could not start input %s: %v", ri.LogName(), err)
+			}
+			services = append(services, si)
+		}
+	}
+
+	prompt := make(chan struct{})
+	go s.readStdin(ctx, prompt, cancel)
+
+	var tick <-chan time.Time
+	if pollInterval > 0 {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var err error
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-quit:
+			break loop
+		case <-collect:
+		case <-prompt:
+		case <-tick:
+		case err = <-written:
+			// The metrics can't be written anymore, stdout is closed.
+			break loop
+		}
+
+		for i, ri := range s.inputs {
+			if gerr := ri.Gather(accs[i]); gerr != nil {
+				accs[i].AddError(gerr)
+			}
+		}
+	}
+
+	stopServices(services)
+	if err != nil {
+		return err
+	}
+	close(metricCh)
+	return <-written
+}
+
+// readStdin sends a prompt for each line read from stdin and cancels the
+// context once stdin is closed.
+func (s *Shim) readStdin(ctx context.Context, prompt chan<- struct{}, cancel context.CancelFunc) {
+	defer cancel()
+
+	scanner := bufio.NewScanner(s.stdin)
+	for scanner.Scan() {
+		select {
+		case prompt <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Shim) writeMetrics(metricCh <-chan telegraf.Metric) error {
+	serializer := influx.NewSerializer()
+	for m := range metricCh {
+		b, err := serializer.Serialize(m)
+		if err != nil {
+			log.Printf("E! Could not serialize metric: %v", err)
+			continue
+		}
+		if _, err := s.stdout.Write(b); err != nil {
+			// Drain the metrics so that the inputs are not blocked.
+			go func() {
+				for range metricCh {
+				}
+			}()
+			return err
+		}
+	}
+	return nil
+}
+
+func stopServices(services []telegraf.ServiceInput) {
+	for _, si := range services {
+		si.Stop()
+	}
+}
@@ -0,0 +1,13 @@
+// +build !windows
+
+package shim
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func listenForCollectMetricsSignals(collect chan os.Signal) {
+	signal.Notify(collect, syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGUSR2)
+}
@@ -0,0 +1,121 @@
+package shim
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShim_GatherOnStdin(t *testing.T) {
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+
+	s := New()
+	s.stdin = stdinReader
+	s.stdout = stdoutWriter
+	require.NoError(t, s.AddInput("test", &testInput{}))
+
+	done := make(chan error)
+	go func() {
+		done <- s.Run(PollIntervalDisabled)
+	}()
+
+	r := bufio.NewReader(stdoutReader)
+	for i := 0; i < 2; i++ {
+		_, err := stdinWriter.Write([]byte("\n"))
+		require.NoError(t, err)
+
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "test value=42i 1000000000\n", line)
+	}
+
+	require.NoError(t, stdinWriter.Close())
+	require.NoError(t, <-done)
+}
+
+func TestShim_PollInterval(t *testing.T) {
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+
+	s := New()
+	s.stdin = stdinReader
+	s.stdout = stdoutWriter
+	require.NoError(t, s.AddInput("test", &testInput{}))
+
+	done := make(chan error)
+	go func() {
+		done <- s.Run(10 * time.Millisecond)
+	}()
+
+	r := bufio.NewReader(stdoutReader)
+	line, err := r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "test value=42i 1000000000\n", line)
+
+	// Keep reading so that the shim is not blocked on writing.
+	go func() {
+		io.Copy(ioutil.Discard, r)
+	}()
+	require.NoError(t, stdinWriter.Close())
+	require.NoError(t, <-done)
+}
+
+func TestShim_LoadConfig(t *testing.T) {
+	inputs.Add("shim_test", func() telegraf.Input {
+		return &testInput{}
+	})
+
+	dir, err := ioutil.TempDir("", "shim")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "plugin.conf")
+	err = ioutil.WriteFile(path, []byte("[[inputs.shim_test]]\n  value = 7\n"), 0640)
+	require.NoError(t, err)
+
+	s := New()
+	require.NoError(t, s.LoadConfig(path))
+	require.Len(t, s.inputs, 1)
+	require.Equal(t, int64(7), s.inputs[0].Input.(*testInput).Value)
+}
+
+func TestShim_NoInputs(t *testing.T) {
+	s := New()
+	require.Error(t, s.Run(PollIntervalDisabled))
+}
+
+type testInput struct {
+	Value int64 `toml:"value"`
+}
+
+func (i *testInput) SampleConfig() string {
+	return ""
+}
+
+func (i *testInput) Description() string {
+	return ""
+}
+
+func (i *testInput) Gather(acc telegraf.Accumulator) error {
+	value := i.Value
+	if value == 0 {
+		value = 42
+	}
+	acc.AddFields("test",
+		map[string]interface{}{
+			"value": value,
+		},
+		map[string]string{},
+		time.Unix(1, 0),
+	)
+	return nil
+}
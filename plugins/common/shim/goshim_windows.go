@@ -0,0 +1,11 @@
+// +build windows
+
+package shim
+
+import (
+	"os"
+)
+
+// Windows has no signals for triggering a gather, only stdin is used.
+func listenForCollectMetricsSignals(collect chan os.Signal) {
+}
@@ -2,6 +2,8 @@ package exec
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"path/filepath"
@@ -12,9 +14,11 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/choice"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
+	"github.com/influxdata/telegraf/selfstat"
 	"github.com/kballard/go-shellquote"
 )
 
@@ -26,9 +30,99 @@ const sampleConfig = `
     "/tmp/collect_*.sh"
   ]
 
-  ## Timeout for each command to complete.
+  ## Timeout for each command to complete.  When it expires the command is
+  ## sent SIGTERM, and SIGKILL if it is still running after kill_grace.
   timeout = "5s"
 
+  ## Time a timed out command is given to flush its output and clean up
+  ## before it is killed.
+  # kill_grace = "5s"
+
+  ## Data written to the stdin of each command.
+  # stdin = ""
+
+  ## Limits of the CPU time and the address space size of each command,
+  ## including the processes it starts.  Only supported on Linux.
+  # cpu_limit = "0s"
+  # memory_limit = "0B"
+
+  ## Glob patterns in the commands are expanded when the plugin starts.  Set
+  ## an interval to expand them again, picking up new scripts without a
+  ## reload.
+  # refresh_interval = "0s"
+
+  ## Exit codes of a successful command, other exit codes are reported as
+  ## errors.
+  # success_exit_codes = [0]
+
+  ## Parse the output of failed commands too, the error is still reported.
+  # ignore_error = false
+
+  ## Number of times a failed command is run again, waiting retry_backoff
+  ## before the first retry and doubling the wait for each further one.
+  # retries = 0
+  # retry_backoff = "1s"
+
+  ## Number of consecutive failed runs after which a command is skipped for
+  ## the quarantine_period, 0 to never skip it.  The command is then run
+  ## again, each further failure doubling the period up to an hour.
+  # quarantine_after = 0
+  # quarantine_period = "1m"
+
+  ## Spread the start of the commands over this duration instead of starting
+  ## them all at once, either at random or evenly spaced.  Should be well
+  ## below the interval, as the gather waits for the last command.
+  # command_jitter = "0s"
+  # command_jitter_mode = "random"
+
+  ## Source of the metric timestamps, one of:
+  ##   "parser"        - the time parsed from the output, if any
+  ##   "now"           - the time the command completed
+  ##   "command_start" - the time the command was started
+  # timestamp = "parser"
+
+  ## Maximum time to wait for all commands of a gather.  Commands still
+  ## running are reported as errors and the metrics they emit later are
+  ## dropped.
+  # gather_timeout = "0s"
+
+  ## Start a run of a command only once its previous run completed, the
+  ## previous run can still be going after the gather_timeout.  Keeps the
+  ## runs of commands with stateful output in order.
+  # serialize_per_command = false
+
+  ## Skip the run of a command while its previous run is still going,
+  ## counting it in the skipped_runs internal stat, to avoid a pile-up of
+  ## slow commands.  Takes precedence over serialize_per_command.
+  # skip_if_running = false
+
+  ## Content encoding of the output of the commands, "identity" or "gzip".
+  # content_encoding = "identity"
+
+  ## Maximum size of the output of each command.  The output beyond it is
+  ## discarded and only the complete lines are parsed, 0 for no limit.
+  # max_output_size = "0B"
+
+  ## Emit an exec_execution metric for each command run, with the exit code,
+  ## the duration, the resource usage and whether the output was truncated.
+  # execution_metrics = false
+
+  ## Emit an exec_event metric for each command run, with the start and end
+  ## time, the exit code and the first line of stderr, for pipelines tracking
+  ## the runs of jobs.
+  # emit_events = false
+
+  ## Handling of the stderr output of the commands, one of:
+  ##   ""       - the first line is added to the error of failed commands
+  ##   "ignore" - the output is discarded
+  ##   "log"    - the output is logged as a warning
+  ##   "metric" - the output is emitted as the stderr field of exec_stderr
+  ##   "merge"  - the output is merged into stdout and parsed
+  # stderr = ""
+
+  ## Maximum number of bytes of stderr output kept.
+  # max_stderr_bytes = 512
+
   ## measurement name suffix (for separating different commands)
   name_suffix = "_mycollector"
 
@@ -37,70 +131,351 @@ const sampleConfig = `
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
   data_format = "influx"
+
+  ## Commands with their own measurement name and tags, the command is
+  ## expanded the same way as the commands array.
+  # [[inputs.exec.entry]]
+  #   command = "/usr/bin/redis-stats --port 6379"
+  #   ## Overrides the timeout, kill_grace and stdin of the plugin.
+  #   timeout = "30s"
+  #   kill_grace = "10s"
+  #   ## Run the command once per interval, on the first gather after each
+  #   ## multiple of the interval, instead of on every gather.
+  #   interval = "5m"
+  #   ## Or run the command on the first gather at or after each time of a
+  #   ## cron schedule, in local time.
+  #   # schedule = "0 2 * * *"
+  #   ## Or run the command only on the first gather, such as for inventory
+  #   ## data.  It is run again when the agent restarts or reloads.
+  #   # run_once = false
+  #   name_override = "redis_custom"
+  #   [inputs.exec.entry.tags]
+  #     service = "redis"
+  #     env = "prod"
 `
 
 const MaxStderrBytes = 512
 
+// defaultKillGrace is the time between terminating and killing a timed out
+// command.
+const defaultKillGrace = 5 * time.Second
+
 type Exec struct {
-	Commands []string
-	Command  string
-	Timeout  internal.Duration
+	Commands            []string
+	Command             string
+	Timeout             internal.Duration
+	KillGrace           internal.Duration `toml:"kill_grace"`
+	Stdin               string            `toml:"stdin"`
+	CPULimit            internal.Duration `toml:"cpu_limit"`
+	MemoryLimit         internal.Size     `toml:"memory_limit"`
+	Entries             []*Entry          `toml:"entry"`
+	RefreshInterval     internal.Duration `toml:"refresh_interval"`
+	SuccessExitCodes    []int             `toml:"success_exit_codes"`
+	IgnoreError         bool              `toml:"ignore_error"`
+	Retries             int               `toml:"retries"`
+	RetryBackoff        internal.Duration `toml:"retry_backoff"`
+	QuarantineAfter     int               `toml:"quarantine_after"`
+	QuarantinePeriod    internal.Duration `toml:"quarantine_period"`
+	CommandJitter       internal.Duration `toml:"command_jitter"`
+	CommandJitterMode   string            `toml:"command_jitter_mode"`
+	Timestamp           string            `toml:"timestamp"`
+	GatherTimeout       internal.Duration `toml:"gather_timeout"`
+	SerializePerCommand bool              `toml:"serialize_per_command"`
+	SkipIfRunning       bool              `toml:"skip_if_running"`
+	ContentEncoding     string            `toml:"content_encoding"`
+	MaxOutputSize       internal.Size     `toml:"max_output_size"`
+	ExecutionMetrics    bool              `toml:"execution_metrics"`
+	EmitEvents          bool              `toml:"emit_events"`
+	Stderr              string            `toml:"stderr"`
+	MaxStderrBytes      int               `toml:"max_stderr_bytes"`
 
 	parser parsers.Parser
 
+	// plan holds the commands with the globs expanded.
+	planLock    sync.Mutex
+	plan        []*command
+	lastRefresh time.Time
+
+	// ctx is cancelled on Stop, terminating the running commands.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+
 	runner Runner
 	Log    telegraf.Logger `toml:"-"`
 }
 
+// Entry is a command with its own options.
+type Entry struct {
+	Command      string            `toml:"command"`
+	Timeout      internal.Duration `toml:"timeout"`
+	KillGrace    internal.Duration `toml:"kill_grace"`
+	Stdin        string            `toml:"stdin"`
+	Interval     internal.Duration `toml:"interval"`
+	Schedule     string            `toml:"schedule"`
+	RunOnce      bool              `toml:"run_once"`
+	NameOverride string            `toml:"name_override"`
+	Tags         map[string]string `toml:"tags"`
+
+	schedule *schedule
+}
+
+// command is a command of the plan, with the options of its entry and the
+// state kept across gathers.
+type command struct {
+	command     string
+	key         commandKey
+	entry       *Entry
+	retries     selfstat.Stat
+	quarantined selfstat.Stat
+	skipped     selfstat.Stat
+
+	// The failed runs of the command by cause.
+	timeouts    selfstat.Stat
+	exitErrors  selfstat.Stat
+	parseErrors selfstat.Stat
+
+	// last is the start of the interval of the last run, next the time of
+	// the next run on the schedule.  ran is set once the command was run.
+	last time.Time
+	next time.Time
+	ran  bool
+
+	// running holds a value while the command runs, when runs of the command
+	// must not overlap.
+	running chan struct{}
+
+	// failures is the number of consecutive failed runs.  After too many
+	// the command is skipped for the period, until the time in quarantine.
+	sync.Mutex
+	failures   int
+	period     time.Duration
+	quarantine time.Time
+}
+
+// maxQuarantinePeriod limits the growth of the quarantine period.
+const maxQuarantinePeriod = time.Hour
+
+// commandKey identifies a command of the plan across refreshes, entry is the
+// index of its entry or -1 for the commands array.
+type commandKey struct {
+	command string
+	entry   int
+}
+
+// inQuarantine returns true if the command is skipped at now.
+func (c *command) inQuarantine(now time.Time) bool {
+	c.Lock()
+	defer c.Unlock()
+	return now.Before(c.quarantine)
+}
+
+// acquire waits for the previous run of the command to complete.
+func (c *command) acquire(ctx context.Context) error {
+	select {
+	case c.running <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tryAcquire marks the command as running, it returns false if the previous
+// run is still going.
+func (c *command) tryAcquire() bool {
+	select {
+	case c.running <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release marks the run of the command as complete.
+func (c *command) release() {
+	<-c.running
+}
+
+// due returns true if the command is to run in the gather at now, and marks
+// it as run.
+func (c *command) due(now time.Time) bool {
+	if c.entry.RunOnce {
+		if c.ran {
+			return false
+		}
+		c.ran = true
+		return true
+	}
+	if c.entry.schedule != nil {
+		if now.Before(c.next) {
+			return false
+		}
+		c.next = c.entry.schedule.next(now)
+		return true
+	}
+	if interval := c.entry.Interval.Duration; interval > 0 {
+		start := now.Truncate(interval)
+		if !c.last.IsZero() && !start.After(c.last) {
+			return false
+		}
+		c.last = start
+	}
+	return true
+}
+
 func NewExec() *Exec {
 	return &Exec{
-		runner:  CommandRunner{},
-		Timeout: internal.Duration{Duration: time.Second * 5},
+		runner:           CommandRunner{},
+		Timeout:          internal.Duration{Duration: time.Second * 5},
+		KillGrace:        internal.Duration{Duration: defaultKillGrace},
+		SuccessExitCodes: []int{0},
+		MaxStderrBytes:   MaxStderrBytes,
+		RetryBackoff:     internal.Duration{Duration: time.Second},
+		QuarantinePeriod: internal.Duration{Duration: time.Minute},
 	}
 }
 
+// CommandSpec describes a command to run.
+type CommandSpec struct {
+	// Command is the command line, split into arguments the way a shell
+	// would.
+	Command string
+	// Timeout is the time after which the command is terminated.
+	Timeout time.Duration
+	// KillGrace is the time between terminating and killing the command.
+	KillGrace time.Duration
+	// Stdin is written to the stdin of the command.
+	Stdin string
+	// CPULimit and MemoryLimit limit the CPU time and address space size of
+	// the command, if set.
+	CPULimit    time.Duration
+	MemoryLimit int64
+	// MergeStderr writes the stderr output of the command to stdout.
+	MergeStderr bool
+	// MaxOutputSize is the maximum number of bytes of stdout kept, if set.
+	MaxOutputSize int64
+}
+
+// Result is the outcome of a command run.
+type Result struct {
+	Stdout []byte
+	Stderr []byte
+	// OutputTruncated is true if stdout was longer than the MaxOutputSize.
+	OutputTruncated bool
+	// ExitCode is the exit code of the command, or -1 if it did not exit on
+	// its own.
+	ExitCode int
+	Duration time.Duration
+
+	// UserTime and SystemTime are the CPU time used by the command.
+	UserTime   time.Duration
+	SystemTime time.Duration
+	// MaxRSS is the maximum resident set size in bytes and BlocksRead and
+	// BlocksWritten the block I/O operations of the command, where known.
+	MaxRSS        int64
+	BlocksRead    int64
+	BlocksWritten int64
+}
+
+// Runner runs a command, terminating it when the context is done.
 type Runner interface {
-	Run(string, time.Duration) ([]byte, []byte, error)
+	Run(ctx context.Context, spec CommandSpec) (Result, error)
 }
 
 type CommandRunner struct{}
 
-func (c CommandRunner) Run(
-	command string,
-	timeout time.Duration,
-) ([]byte, []byte, error) {
-	split_cmd, err := shellquote.Split(command)
+func (c CommandRunner) Run(ctx context.Context, spec CommandSpec) (Result, error) {
+	split_cmd, err := shellquote.Split(spec.Command)
 	if err != nil || len(split_cmd) == 0 {
-		return nil, nil, fmt.Errorf("exec: unable to parse command, %s", err)
+		return Result{ExitCode: -1}, fmt.Errorf("exec: unable to parse command, %s", err)
 	}
 
 	cmd := exec.Command(split_cmd[0], split_cmd[1:]...)
 
 	var (
-		out    bytes.Buffer
+		out    = limitedBuffer{max: spec.MaxOutputSize}
 		stderr bytes.Buffer
 	)
+	if spec.Stdin != "" {
+		cmd.Stdin = strings.NewReader(spec.Stdin)
+	}
 	cmd.Stdout = &out
 	cmd.Stderr = &stderr
+	if spec.MergeStderr {
+		cmd.Stderr = &out
+	}
 
-	runErr := internal.RunTimeout(cmd, timeout)
+	start := time.Now()
+	runErr := runTimeout(ctx, cmd, spec)
+	result := Result{
+		OutputTruncated: out.truncated,
+		ExitCode:        exitCode(runErr),
+		Duration:        time.Since(start),
+	}
+	if cmd.ProcessState != nil {
+		result.UserTime = cmd.ProcessState.UserTime()
+		result.SystemTime = cmd.ProcessState.SystemTime()
+		setUsage(&result, cmd.ProcessState)
+	}
 
-	out = removeCarriageReturns(out)
+	stdout := removeCarriageReturns(out.buf)
 	if stderr.Len() > 0 {
 		stderr = removeCarriageReturns(stderr)
-		stderr = truncate(stderr)
 	}
+	result.Stdout = stdout.Bytes()
+	result.Stderr = stderr.Bytes()
+
+	return result, runErr
+}
+
+// exitCode returns the exit code of a command run with the error, or -1 if
+// the command did not exit on its own.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if status, ok := internal.ExitStatus(err); ok {
+		return status
+	}
+	return -1
+}
 
-	return out.Bytes(), stderr.Bytes(), runErr
+// limitedBuffer is a buffer keeping the first max bytes written to it, or all
+// of them if max is 0.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int64
+	truncated bool
 }
 
-func truncate(buf bytes.Buffer) bytes.Buffer {
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.max > 0 {
+		if room := b.max - int64(b.buf.Len()); int64(len(p)) > room {
+			b.truncated = true
+			if room > 0 {
+				b.buf.Write(p[:room])
+			}
+			return len(p), nil
+		}
+	}
+	return b.buf.Write(p)
+}
+
+// truncate limits the buffer to max bytes and, unless multiline is set, to
+// the first line.  It returns true if the buffer was shortened.
+func truncate(buf bytes.Buffer, max int, multiline bool) (bytes.Buffer, bool) {
 	// Limit the number of bytes.
 	didTruncate := false
-	if buf.Len() > MaxStderrBytes {
-		buf.Truncate(MaxStderrBytes)
+	if buf.Len() > max {
+		buf.Truncate(max)
 		didTruncate = true
 	}
+	if multiline {
+		if didTruncate {
+			buf.WriteString("...")
+		}
+		return buf, didTruncate
+	}
 	if i := bytes.IndexByte(buf.Bytes(), '\n'); i > 0 {
 		// Only show truncation if the newline wasn't the last character.
 		if i < buf.Len()-1 {
@@ -111,7 +486,7 @@ func truncate(buf bytes.Buffer) bytes.Buffer {
 	if didTruncate {
 		buf.WriteString("...")
 	}
-	return buf
+	return buf, didTruncate
 }
 
 // removeCarriageReturns removes all carriage returns from the input if the
@@ -140,19 +515,119 @@ func removeCarriageReturns(b bytes.Buffer) bytes.Buffer {
 
 }
 
-func (e *Exec) ProcessCommand(command string, acc telegraf.Accumulator, wg *sync.WaitGroup) {
-	defer wg.Done()
+func (e *Exec) processCommand(ctx context.Context, c *command, acc telegraf.Accumulator) {
 	_, isNagios := e.parser.(*nagios.NagiosParser)
 
-	out, errbuf, runErr := e.runner.Run(command, e.Timeout.Duration)
-	if !isNagios && runErr != nil {
-		err := fmt.Errorf("exec: %s for command '%s': %s", runErr, command, string(errbuf))
-		acc.AddError(err)
+	spec := e.commandSpec(c)
+	start := time.Now()
+	res, runErr := e.runner.Run(ctx, spec)
+
+	// The exit code is the state reported with nagios, so it is not retried.
+	backoff := e.RetryBackoff.Duration
+	for i := 0; i < e.Retries && !isNagios && runErr != nil && !e.isSuccess(runErr); i++ {
+		c.retries.Incr(1)
+		if err := internal.SleepContext(ctx, backoff); err != nil {
+			break
+		}
+		backoff *= 2
+
+		start = time.Now()
+		res, runErr = e.runner.Run(ctx, spec)
+	}
+
+	multiline := e.Stderr == "log" || e.Stderr == "metric"
+	errbuf, stderrTruncated := truncate(*bytes.NewBuffer(res.Stderr), e.MaxStderrBytes, multiline)
+	if errbuf.Len() > 0 {
+		switch e.Stderr {
+		case "log":
+			e.Log.Warnf("Command '%s' wrote to stderr: %s", c.command, errbuf.String())
+		case "metric":
+			acc.AddFields("exec_stderr",
+				map[string]interface{}{"stderr": errbuf.String()},
+				map[string]string{"command": c.command})
+		}
+	}
+	tags := map[string]string{"command": c.command}
+	if e.ExecutionMetrics {
+		fields := map[string]interface{}{
+			"exit_code":        res.ExitCode,
+			"duration_ms":      res.Duration.Nanoseconds() / int64(time.Millisecond),
+			"stderr_truncated": stderrTruncated,
+			"output_truncated": res.OutputTruncated,
+			"user_time_ms":     res.UserTime.Nanoseconds() / int64(time.Millisecond),
+			"system_time_ms":   res.SystemTime.Nanoseconds() / int64(time.Millisecond),
+		}
+		if res.MaxRSS > 0 {
+			fields["max_rss_bytes"] = res.MaxRSS
+			fields["blocks_read"] = res.BlocksRead
+			fields["blocks_written"] = res.BlocksWritten
+		}
+		acc.AddFields("exec_execution", fields, tags)
+	}
+	if e.EmitEvents {
+		firstLine := errbuf
+		if multiline {
+			firstLine, _ = truncate(*bytes.NewBuffer(res.Stderr), e.MaxStderrBytes, false)
+		}
+		end := start.Add(res.Duration)
+		acc.AddFields("exec_event",
+			map[string]interface{}{
+				"start_time": start.UnixNano(),
+				"end_time":   end.UnixNano(),
+				"exit_code":  res.ExitCode,
+				"stderr":     firstLine.String(),
+			}, tags, end)
+	}
+
+	// Commands terminated by Stop are not errors, the output of those that
+	// exited cleanly is still parsed.
+	if runErr != nil && ctx.Err() != nil {
+		e.Log.Debugf("Command '%s' stopped: %s", c.command, runErr)
 		return
 	}
 
+	failed := !isNagios && runErr != nil && !e.isSuccess(runErr)
+	e.recordRun(c, failed)
+
+	if failed {
+		if runErr == internal.TimeoutErr {
+			c.timeouts.Incr(1)
+		} else {
+			c.exitErrors.Incr(1)
+		}
+		err := fmt.Errorf("exec: %s for command '%s'", runErr, c.command)
+		if e.Stderr == "" {
+			err = fmt.Errorf("%s: %s", err, errbuf.String())
+		}
+		acc.AddError(err)
+		if !e.IgnoreError {
+			return
+		}
+	}
+
+	out := res.Stdout
+	if e.ContentEncoding != "" && e.ContentEncoding != "identity" {
+		decoder, err := internal.NewContentDecoder(e.ContentEncoding)
+		if err == nil {
+			out, err = decoder.Decode(out)
+		}
+		if err != nil {
+			c.parseErrors.Incr(1)
+			acc.AddError(fmt.Errorf("exec: decoding output of command '%s': %v", c.command, err))
+			return
+		}
+	}
+	if res.OutputTruncated {
+		e.Log.Warnf("Output of command '%s' truncated to %d bytes", c.command, e.MaxOutputSize.Size)
+		// Only parse the complete lines.
+		if i := bytes.LastIndexByte(out, '\n'); i >= 0 {
+			out = out[:i+1]
+		}
+	}
+
 	metrics, err := e.parser.Parse(out)
 	if err != nil {
+		c.parseErrors.Incr(1)
 		acc.AddError(err)
 		return
 	}
@@ -165,10 +640,101 @@ func (e *Exec) ProcessCommand(command string, acc telegraf.Accumulator, wg *sync
 	}
 
 	for _, m := range metrics {
+		switch e.Timestamp {
+		case "now":
+			m.SetTime(start.Add(res.Duration))
+		case "command_start":
+			m.SetTime(start)
+		}
+		if c.entry.NameOverride != "" {
+			m.SetName(c.entry.NameOverride)
+		}
+		for k, v := range c.entry.Tags {
+			if !m.HasTag(k) {
+				m.AddTag(k, v)
+			}
+		}
 		acc.AddMetric(m)
 	}
 }
 
+// recordRun updates the consecutive failures of the command, putting it in
+// quarantine once there are too many.
+func (e *Exec) recordRun(c *command, failed bool) {
+	if e.QuarantineAfter <= 0 {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if !failed {
+		if c.period > 0 {
+			e.Log.Infof("Command '%s' succeeded, ending its quarantine", c.command)
+		}
+		c.failures = 0
+		c.period = 0
+		c.quarantined.Set(0)
+		return
+	}
+
+	c.failures++
+	if c.failures < e.QuarantineAfter {
+		return
+	}
+	if c.period == 0 {
+		c.period = e.QuarantinePeriod.Duration
+	} else if c.period < maxQuarantinePeriod {
+		c.period *= 2
+		if c.period > maxQuarantinePeriod {
+			c.period = maxQuarantinePeriod
+		}
+	}
+	c.quarantine = time.Now().Add(c.period)
+	c.quarantined.Set(1)
+	e.Log.Warnf("Command '%s' failed %d times in a row, skipping it for %s",
+		c.command, c.failures, c.period)
+}
+
+// commandSpec returns the spec of a command, with the options of its entry
+// taking precedence over the options of the plugin.
+func (e *Exec) commandSpec(c *command) CommandSpec {
+	spec := CommandSpec{
+		Command:       c.command,
+		Timeout:       e.Timeout.Duration,
+		KillGrace:     e.KillGrace.Duration,
+		Stdin:         e.Stdin,
+		CPULimit:      e.CPULimit.Duration,
+		MemoryLimit:   e.MemoryLimit.Size,
+		MergeStderr:   e.Stderr == "merge",
+		MaxOutputSize: e.MaxOutputSize.Size,
+	}
+	if c.entry.Timeout.Duration > 0 {
+		spec.Timeout = c.entry.Timeout.Duration
+	}
+	if c.entry.KillGrace.Duration > 0 {
+		spec.KillGrace = c.entry.KillGrace.Duration
+	}
+	if c.entry.Stdin != "" {
+		spec.Stdin = c.entry.Stdin
+	}
+	return spec
+}
+
+// isSuccess returns true if the command exited with one of the success exit
+// codes.
+func (e *Exec) isSuccess(err error) bool {
+	status, ok := internal.ExitStatus(err)
+	if !ok {
+		return false
+	}
+	for _, code := range e.SuccessExitCodes {
+		if status == code {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *Exec) SampleConfig() string {
 	return sampleConfig
 }
@@ -181,55 +747,334 @@ func (e *Exec) SetParser(parser parsers.Parser) {
 	e.parser = parser
 }
 
+// Start implements telegraf.ServiceInput, the commands are run on Gather.
+func (e *Exec) Start(telegraf.Accumulator) error {
+	return nil
+}
+
+// Stop terminates the running commands and waits for them to complete, so
+// that the metrics of those exiting cleanly are still added.
+func (e *Exec) Stop() {
+	e.cancel()
+	e.running.Wait()
+}
+
 func (e *Exec) Gather(acc telegraf.Accumulator) error {
 	var wg sync.WaitGroup
-	// Legacy single command support
-	if e.Command != "" {
-		e.Commands = append(e.Commands, e.Command)
-		e.Command = ""
-	}
 
-	commands := make([]string, 0, len(e.Commands))
-	for _, pattern := range e.Commands {
-		cmdAndArgs := strings.SplitN(pattern, " ", 2)
-		if len(cmdAndArgs) == 0 {
+	if e.refreshDue() {
+		if err := e.Refresh(); err != nil {
+			acc.AddError(err)
+		}
+	}
+	e.planLock.Lock()
+	var plan []*command
+	now := time.Now()
+	for _, c := range e.plan {
+		if c.inQuarantine(now) || !c.due(now) {
 			continue
 		}
-
-		matches, err := filepath.Glob(cmdAndArgs[0])
-		if err != nil {
-			acc.AddError(err)
+		if e.SkipIfRunning && !c.tryAcquire() {
+			c.skipped.Incr(1)
+			e.Log.Debugf("Command '%s' is still running, skipping it", c.command)
 			continue
 		}
+		plan = append(plan, c)
+	}
+	e.planLock.Unlock()
 
-		if len(matches) == 0 {
-			// There were no matches with the glob pattern, so let's assume
-			// that the command is in PATH and just run it as it is
-			commands = append(commands, pattern)
-		} else {
-			// There were matches, so we'll append each match together with
-			// the arguments to the commands slice
-			for _, match := range matches {
-				if len(cmdAndArgs) == 1 {
-					commands = append(commands, match)
-				} else {
-					commands = append(commands,
-						strings.Join([]string{match, cmdAndArgs[1]}, " "))
+	dacc := &deadlineAccumulator{
+		Accumulator: acc,
+		finished:    make([]bool, len(plan)),
+	}
+	wg.Add(len(plan))
+	e.running.Add(len(plan))
+	for i, c := range plan {
+		delay := e.commandDelay(i, len(plan))
+		go func(i int, c *command) {
+			defer e.running.Done()
+			defer wg.Done()
+			defer dacc.finish(i)
+			if e.SkipIfRunning {
+				// The command was marked as running when planning.
+				defer c.release()
+			}
+			if err := internal.SleepContext(e.ctx, delay); err != nil {
+				return
+			}
+			if e.SerializePerCommand && !e.SkipIfRunning {
+				if err := c.acquire(e.ctx); err != nil {
+					return
 				}
+				defer c.release()
 			}
+			e.processCommand(e.ctx, c, dacc)
+		}(i, c)
+	}
+
+	if e.GatherTimeout.Duration <= 0 {
+		wg.Wait()
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(e.GatherTimeout.Duration)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		for _, i := range dacc.expire() {
+			plan[i].timeouts.Incr(1)
+			acc.AddError(fmt.Errorf("exec: command '%s' did not complete within the gather timeout", plan[i].command))
+		}
+	}
+	return nil
+}
+
+// deadlineAccumulator drops the metrics and errors of the commands still
+// running when the gather timeout expired.
+type deadlineAccumulator struct {
+	telegraf.Accumulator
+
+	sync.Mutex
+	finished []bool
+	expired  bool
+}
+
+func (a *deadlineAccumulator) AddFields(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.Lock()
+	defer a.Unlock()
+	if !a.expired {
+		a.Accumulator.AddFields(measurement, fields, tags, t...)
+	}
+}
+
+func (a *deadlineAccumulator) AddMetric(m telegraf.Metric) {
+	a.Lock()
+	defer a.Unlock()
+	if !a.expired {
+		a.Accumulator.AddMetric(m)
+	}
+}
+
+func (a *deadlineAccumulator) AddError(err error) {
+	a.Lock()
+	defer a.Unlock()
+	if !a.expired {
+		a.Accumulator.AddError(err)
+	}
+}
+
+// finish marks the i-th command as finished.
+func (a *deadlineAccumulator) finish(i int) {
+	a.Lock()
+	defer a.Unlock()
+	a.finished[i] = true
+}
+
+// expire drops everything added from now on and returns the indexes of the
+// commands not finished.
+func (a *deadlineAccumulator) expire() []int {
+	a.Lock()
+	defer a.Unlock()
+	a.expired = true
+
+	var running []int
+	for i, finished := range a.finished {
+		if !finished {
+			running = append(running, i)
 		}
 	}
+	return running
+}
 
-	wg.Add(len(commands))
-	for _, command := range commands {
-		go e.ProcessCommand(command, acc, &wg)
+// commandDelay returns the delay before starting the i-th of n commands.
+func (e *Exec) commandDelay(i, n int) time.Duration {
+	jitter := e.CommandJitter.Duration
+	if e.CommandJitterMode == "even" {
+		return jitter * time.Duration(i) / time.Duration(n)
 	}
-	wg.Wait()
+	return internal.RandomDuration(jitter)
+}
+
+// Plan returns the commands run on each gather, with the globs expanded.
+func (e *Exec) Plan() []string {
+	e.planLock.Lock()
+	defer e.planLock.Unlock()
+
+	commands := make([]string, 0, len(e.plan))
+	for _, c := range e.plan {
+		commands = append(commands, c.command)
+	}
+	return commands
+}
+
+func (e *Exec) refreshDue() bool {
+	e.planLock.Lock()
+	defer e.planLock.Unlock()
+	return e.RefreshInterval.Duration > 0 && time.Since(e.lastRefresh) >= e.RefreshInterval.Duration
+}
+
+// Refresh expands the globs of the commands again.  The plan is kept if any
+// of the patterns is invalid, and the commands still matched keep their state.
+func (e *Exec) Refresh() error {
+	e.planLock.Lock()
+	defer e.planLock.Unlock()
+
+	existing := make(map[commandKey]*command, len(e.plan))
+	for _, c := range e.plan {
+		existing[c.key] = c
+	}
+
+	plan := make([]*command, 0, len(e.Commands)+len(e.Entries))
+	plain := &Entry{}
+	for _, pattern := range e.Commands {
+		commands, err := expandCommand(pattern)
+		if err != nil {
+			return err
+		}
+		for _, c := range commands {
+			plan = append(plan, e.newCommand(existing, commandKey{c, -1}, plain))
+		}
+	}
+	for i, entry := range e.Entries {
+		commands, err := expandCommand(entry.Command)
+		if err != nil {
+			return err
+		}
+		for _, c := range commands {
+			plan = append(plan, e.newCommand(existing, commandKey{c, i}, entry))
+		}
+	}
+
+	e.plan = plan
+	e.lastRefresh = time.Now()
 	return nil
 }
 
+// newCommand returns the command of the plan with the key, reusing the
+// existing one if there is.
+func (e *Exec) newCommand(existing map[commandKey]*command, key commandKey, entry *Entry) *command {
+	if c, ok := existing[key]; ok {
+		c.entry = entry
+		return c
+	}
+
+	tags := map[string]string{"command": key.command}
+	c := &command{
+		command:     key.command,
+		key:         key,
+		entry:       entry,
+		retries:     selfstat.Register("exec", "retries", tags),
+		quarantined: selfstat.Register("exec", "quarantined", tags),
+		skipped:     selfstat.Register("exec", "skipped_runs", tags),
+		timeouts:    selfstat.Register("exec", "timeouts", tags),
+		exitErrors:  selfstat.Register("exec", "exit_errors", tags),
+		parseErrors: selfstat.Register("exec", "parse_errors", tags),
+		running:     make(chan struct{}, 1),
+	}
+	if entry.schedule != nil {
+		c.next = entry.schedule.next(time.Now())
+	}
+	return c
+}
+
+// expandCommand returns the commands to run for a pattern with the globs
+// expanded.
+func expandCommand(pattern string) ([]string, error) {
+	cmdAndArgs := strings.SplitN(pattern, " ", 2)
+	if len(cmdAndArgs) == 0 {
+		return nil, nil
+	}
+
+	matches, err := filepath.Glob(cmdAndArgs[0])
+	if err != nil {
+		return nil, fmt.Errorf("expanding %q: %v", pattern, err)
+	}
+
+	if len(matches) == 0 {
+		// There were no matches with the glob pattern, so let's assume
+		// that the command is in PATH and just run it as it is
+		return []string{pattern}, nil
+	}
+
+	// There were matches, so we'll append each match together with
+	// the arguments to the commands slice
+	commands := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if len(cmdAndArgs) == 1 {
+			commands = append(commands, match)
+		} else {
+			commands = append(commands,
+				strings.Join([]string{match, cmdAndArgs[1]}, " "))
+		}
+	}
+	return commands, nil
+}
+
 func (e *Exec) Init() error {
-	return nil
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+
+	if err := choice.Check(e.Stderr, []string{"", "ignore", "log", "metric", "merge"}); err != nil {
+		return fmt.Errorf("stderr: %v", err)
+	}
+	if e.CommandJitterMode == "" {
+		e.CommandJitterMode = "random"
+	}
+	if err := choice.Check(e.CommandJitterMode, []string{"random", "even"}); err != nil {
+		return fmt.Errorf("command_jitter_mode: %v", err)
+	}
+	if e.Timestamp == "" {
+		e.Timestamp = "parser"
+	}
+	if err := choice.Check(e.Timestamp, []string{"parser", "now", "command_start"}); err != nil {
+		return fmt.Errorf("timestamp: %v", err)
+	}
+	if _, err := internal.NewContentDecoder(e.ContentEncoding); err != nil {
+		return err
+	}
+	if e.MaxStderrBytes <= 0 {
+		e.MaxStderrBytes = MaxStderrBytes
+	}
+	if (e.CPULimit.Duration > 0 || e.MemoryLimit.Size > 0) && !limitsSupported {
+		return errors.New("cpu_limit and memory_limit are only supported on Linux")
+	}
+
+	for _, entry := range e.Entries {
+		if entry.Command == "" {
+			return errors.New("command is required in each entry")
+		}
+		if entry.RunOnce && (entry.Interval.Duration > 0 || entry.Schedule != "") {
+			return fmt.Errorf("entry '%s': run_once cannot be combined with interval or schedule", entry.Command)
+		}
+		if entry.Schedule != "" {
+			if entry.Interval.Duration > 0 {
+				return fmt.Errorf("entry '%s': interval and schedule cannot both be set", entry.Command)
+			}
+			schedule, err := parseSchedule(entry.Schedule)
+			if err != nil {
+				return fmt.Errorf("entry '%s': schedule: %v", entry.Command, err)
+			}
+			if schedule.next(time.Now()).IsZero() {
+				return fmt.Errorf("entry '%s': schedule %q never matches", entry.Command, entry.Schedule)
+			}
+			entry.schedule = schedule
+		}
+	}
+
+	// Legacy single command support
+	if e.Command != "" {
+		if !choice.Contains(e.Command, e.Commands) {
+			e.Commands = append(e.Commands, e.Command)
+		}
+		e.Command = ""
+	}
+	return e.Refresh()
 }
 
 func init() {
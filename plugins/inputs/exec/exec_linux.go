@@ -0,0 +1,41 @@
+// +build linux
+
+package exec
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// limitsSupported is true if resource limits can be set on the commands.
+const limitsSupported = true
+
+// setLimits sets the resource limits of a started process, they are inherited
+// by the processes it starts.  The CPU time is rounded up to whole seconds.
+func setLimits(pid int, cpu time.Duration, memory int64) error {
+	if cpu > 0 {
+		secs := uint64((cpu + time.Second - 1) / time.Second)
+		limit := &syscall.Rlimit{Cur: secs, Max: secs}
+		if err := prlimit(pid, syscall.RLIMIT_CPU, limit); err != nil {
+			return fmt.Errorf("setting cpu limit: %v", err)
+		}
+	}
+	if memory > 0 {
+		limit := &syscall.Rlimit{Cur: uint64(memory), Max: uint64(memory)}
+		if err := prlimit(pid, syscall.RLIMIT_AS, limit); err != nil {
+			return fmt.Errorf("setting memory limit: %v", err)
+		}
+	}
+	return nil
+}
+
+func prlimit(pid int, resource int, limit *syscall.Rlimit) error {
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64,
+		uintptr(pid), uintptr(resource), uintptr(unsafe.Pointer(limit)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+// +build linux
+
+package exec
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunLimits(t *testing.T) {
+	// The limits are set after the start, so wait before reading them.
+	res, err := CommandRunner{}.Run(context.Background(), CommandSpec{
+		Command:     "sh -c 'sleep 0.2; ulimit -t; ulimit -v'",
+		Timeout:     5 * time.Second,
+		CPULimit:    2500 * time.Millisecond,
+		MemoryLimit: 512 * 1024 * 1024,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "3\n524288\n", string(res.Stdout))
+}
@@ -0,0 +1,14 @@
+// +build !linux
+
+package exec
+
+import (
+	"time"
+)
+
+// limitsSupported is true if resource limits can be set on the commands.
+const limitsSupported = false
+
+func setLimits(pid int, cpu time.Duration, memory int64) error {
+	return nil
+}
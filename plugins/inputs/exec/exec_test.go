@@ -2,13 +2,23 @@ package exec
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/testutil"
+	"github.com/influxdata/toml"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -86,8 +96,23 @@ func newRunnerMock(out []byte, errout []byte, err error) Runner {
 	}
 }
 
-func (r runnerMock) Run(command string, _ time.Duration) ([]byte, []byte, error) {
-	return r.out, r.errout, r.err
+func (r runnerMock) Run(_ context.Context, _ CommandSpec) (Result, error) {
+	return Result{Stdout: r.out, Stderr: r.errout, ExitCode: exitCode(r.err)}, r.err
+}
+
+// timeoutRunner records the timeout and kill grace of each command.
+type timeoutRunner struct {
+	sync.Mutex
+	timeouts map[string]time.Duration
+	graces   map[string]time.Duration
+}
+
+func (r *timeoutRunner) Run(_ context.Context, spec CommandSpec) (Result, error) {
+	r.Lock()
+	defer r.Unlock()
+	r.timeouts[spec.Command] = spec.Timeout
+	r.graces[spec.Command] = spec.KillGrace
+	return Result{}, nil
 }
 
 func TestExec(t *testing.T) {
@@ -102,6 +127,8 @@ func TestExec(t *testing.T) {
 		parser:   parser,
 	}
 
+	require.NoError(t, e.Init())
+
 	var acc testutil.Accumulator
 	err := acc.GatherError(e.Gather)
 	require.NoError(t, err)
@@ -131,6 +158,7 @@ func TestExecMalformed(t *testing.T) {
 		Commands: []string{"badcommand arg1"},
 		parser:   parser,
 	}
+	require.NoError(t, e.Init())
 
 	var acc testutil.Accumulator
 	require.Error(t, acc.GatherError(e.Gather))
@@ -148,6 +176,7 @@ func TestCommandError(t *testing.T) {
 		Commands: []string{"badcommand"},
 		parser:   parser,
 	}
+	require.NoError(t, e.Init())
 
 	var acc testutil.Accumulator
 	require.Error(t, acc.GatherError(e.Gather))
@@ -160,6 +189,8 @@ func TestExecCommandWithGlob(t *testing.T) {
 	e.Commands = []string{"/bin/ech* metric_value"}
 	e.SetParser(parser)
 
+	require.NoError(t, e.Init())
+
 	var acc testutil.Accumulator
 	err := acc.GatherError(e.Gather)
 	require.NoError(t, err)
@@ -176,6 +207,8 @@ func TestExecCommandWithoutGlob(t *testing.T) {
 	e.Commands = []string{"/bin/echo metric_value"}
 	e.SetParser(parser)
 
+	require.NoError(t, e.Init())
+
 	var acc testutil.Accumulator
 	err := acc.GatherError(e.Gather)
 	require.NoError(t, err)
@@ -192,6 +225,8 @@ func TestExecCommandWithoutGlobAndPath(t *testing.T) {
 	e.Commands = []string{"echo metric_value"}
 	e.SetParser(parser)
 
+	require.NoError(t, e.Init())
+
 	var acc testutil.Accumulator
 	err := acc.GatherError(e.Gather)
 	require.NoError(t, err)
@@ -202,6 +237,704 @@ func TestExecCommandWithoutGlobAndPath(t *testing.T) {
 	acc.AssertContainsFields(t, "metric", fields)
 }
 
+func TestExecLegacyCommand(t *testing.T) {
+	parser, _ := parsers.NewValueParser("metric", "string", nil)
+	e := NewExec()
+	e.Command = "/bin/echo metric_value"
+	e.Commands = []string{"/bin/echo metric_value"}
+	e.SetParser(parser)
+	require.NoError(t, e.Init())
+	require.Equal(t, []string{"/bin/echo metric_value"}, e.Commands)
+
+	for i := 0; i < 2; i++ {
+		var acc testutil.Accumulator
+		err := acc.GatherError(e.Gather)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(acc.Metrics))
+	}
+	require.Equal(t, []string{"/bin/echo metric_value"}, e.Commands)
+}
+
+func TestExecPlan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exec")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	touch := func(name string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), nil, 0755))
+	}
+	touch("collect_a.sh")
+
+	e := NewExec()
+	e.Commands = []string{filepath.Join(dir, "collect_*.sh") + " --verbose", "echo a"}
+	require.NoError(t, e.Init())
+
+	expected := []string{filepath.Join(dir, "collect_a.sh") + " --verbose", "echo a"}
+	require.Equal(t, expected, e.Plan())
+
+	// New scripts are only picked up when refreshing.
+	touch("collect_b.sh")
+	require.Equal(t, expected, e.Plan())
+
+	require.NoError(t, e.Refresh())
+	expected = []string{
+		filepath.Join(dir, "collect_a.sh") + " --verbose",
+		filepath.Join(dir, "collect_b.sh") + " --verbose",
+		"echo a",
+	}
+	require.Equal(t, expected, e.Plan())
+}
+
+func TestExecPlanInvalidPattern(t *testing.T) {
+	e := NewExec()
+	e.Commands = []string{"/tmp/[ arg"}
+	require.Error(t, e.Init())
+}
+
+func TestExecEntries(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	e := NewExec()
+	e.runner = newRunnerMock([]byte(lineProtocol), nil, nil)
+	e.SetParser(parser)
+
+	conf := []byte(`
+commands = ["plain"]
+
+[[entry]]
+  command = "redis-stats"
+  name_override = "redis_custom"
+  [entry.tags]
+    service = "redis"
+    host = "bar"
+`)
+	require.NoError(t, toml.Unmarshal(conf, e))
+	require.NoError(t, e.Init())
+	require.Equal(t, []string{"plain", "redis-stats"}, e.Plan())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(e.Gather))
+
+	// Tags from the command output are kept.
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "foo", "datacenter": "us-east"},
+			map[string]interface{}{"usage_idle": float64(99), "usage_busy": float64(1)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("redis_custom",
+			map[string]string{"host": "foo", "datacenter": "us-east", "service": "redis"},
+			map[string]interface{}{"usage_idle": float64(99), "usage_busy": float64(1)},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(),
+		testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestExecEntryTimeout(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	runner := &timeoutRunner{
+		timeouts: make(map[string]time.Duration),
+		graces:   make(map[string]time.Duration),
+	}
+	e := NewExec()
+	e.runner = runner
+	e.SetParser(parser)
+	e.Commands = []string{"default"}
+	e.Entries = []*Entry{
+		{
+			Command:   "slow",
+			Timeout:   internal.Duration{Duration: time.Minute},
+			KillGrace: internal.Duration{Duration: 30 * time.Second},
+		},
+		{Command: "inherited"},
+	}
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(e.Gather))
+	require.Equal(t, map[string]time.Duration{
+		"default":   5 * time.Second,
+		"slow":      time.Minute,
+		"inherited": 5 * time.Second,
+	}, runner.timeouts)
+	require.Equal(t, map[string]time.Duration{
+		"default":   defaultKillGrace,
+		"slow":      30 * time.Second,
+		"inherited": defaultKillGrace,
+	}, runner.graces)
+}
+
+// flakyRunner fails the first runs of a command.
+type flakyRunner struct {
+	failures int
+	runs     int
+}
+
+func (r *flakyRunner) Run(_ context.Context, _ CommandSpec) (Result, error) {
+	r.runs++
+	if r.runs <= r.failures {
+		return Result{Stderr: []byte("not ready"), ExitCode: 1}, errors.New("exit status 1")
+	}
+	return Result{Stdout: []byte(validJson)}, nil
+}
+
+func TestExecRetries(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "json",
+		MetricName: "exec",
+	})
+	runner := &flakyRunner{failures: 2}
+	e := NewExec()
+	e.runner = runner
+	e.SetParser(parser)
+	e.Commands = []string{"flaky-retries"}
+	e.Retries = 2
+	e.RetryBackoff = internal.Duration{Duration: time.Millisecond}
+	require.NoError(t, e.Init())
+	e.plan[0].retries.Set(0)
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(e.Gather))
+	require.Equal(t, 3, runner.runs)
+	require.True(t, acc.HasMeasurement("exec"))
+	require.Equal(t, int64(2), e.plan[0].retries.Get())
+}
+
+func TestExecRetriesExhausted(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "json",
+		MetricName: "exec",
+	})
+	runner := &flakyRunner{failures: 3}
+	e := NewExec()
+	e.runner = runner
+	e.SetParser(parser)
+	e.Commands = []string{"flaky-exhausted"}
+	e.Retries = 2
+	e.RetryBackoff = internal.Duration{Duration: time.Millisecond}
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(e.Gather))
+	require.Equal(t, 3, runner.runs)
+	require.False(t, acc.HasMeasurement("exec"))
+}
+
+func TestExecQuarantine(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "json",
+		MetricName: "exec",
+	})
+	runner := &flakyRunner{failures: 3}
+	e := NewExec()
+	e.Log = testutil.Logger{}
+	e.runner = runner
+	e.SetParser(parser)
+	e.Commands = []string{"flaky-quarantine"}
+	e.QuarantineAfter = 2
+	e.QuarantinePeriod = internal.Duration{Duration: 10 * time.Minute}
+	require.NoError(t, e.Init())
+	c := e.plan[0]
+	c.quarantined.Set(0)
+
+	gather := func() {
+		var acc testutil.Accumulator
+		require.NoError(t, e.Gather(&acc))
+	}
+
+	// The command is skipped after the second failure.
+	for i := 0; i < 3; i++ {
+		gather()
+	}
+	require.Equal(t, 2, runner.runs)
+	require.Equal(t, int64(1), c.quarantined.Get())
+	require.Equal(t, 10*time.Minute, c.period)
+
+	// Failing again at the end of the quarantine doubles the period.
+	c.quarantine = time.Now()
+	gather()
+	require.Equal(t, 3, runner.runs)
+	require.Equal(t, 20*time.Minute, c.period)
+
+	// A successful run ends the quarantine.
+	c.quarantine = time.Now()
+	gather()
+	require.Equal(t, 4, runner.runs)
+	require.Equal(t, int64(0), c.quarantined.Get())
+	require.Equal(t, 0, c.failures)
+}
+
+func TestExecCommandJitterEven(t *testing.T) {
+	e := NewExec()
+	e.Commands = []string{"foo"}
+	e.CommandJitter = internal.Duration{Duration: 4 * time.Second}
+	e.CommandJitterMode = "even"
+	require.NoError(t, e.Init())
+
+	var delays []time.Duration
+	for i := 0; i < 4; i++ {
+		delays = append(delays, e.commandDelay(i, 4))
+	}
+	require.Equal(t, []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second}, delays)
+}
+
+func TestExecCommandJitterRandom(t *testing.T) {
+	e := NewExec()
+	e.Commands = []string{"foo"}
+	e.CommandJitter = internal.Duration{Duration: time.Second}
+	require.NoError(t, e.Init())
+	require.Equal(t, "random", e.CommandJitterMode)
+
+	for i := 0; i < 10; i++ {
+		delay := e.commandDelay(i, 10)
+		require.True(t, delay >= 0 && delay < time.Second)
+	}
+}
+
+func TestExecInvalidCommandJitterMode(t *testing.T) {
+	e := NewExec()
+	e.Commands = []string{"foo"}
+	e.CommandJitterMode = "burst"
+	require.Error(t, e.Init())
+}
+
+// slowRunner returns the output after a delay.
+type slowRunner struct {
+	out   []byte
+	delay time.Duration
+}
+
+func (r slowRunner) Run(_ context.Context, _ CommandSpec) (Result, error) {
+	time.Sleep(r.delay)
+	return Result{Stdout: r.out, Duration: r.delay}, nil
+}
+
+func TestExecTimestamp(t *testing.T) {
+	tests := []struct {
+		timestamp string
+		check     func(t *testing.T, before, ts time.Time)
+	}{
+		{
+			timestamp: "parser",
+			check: func(t *testing.T, _, ts time.Time) {
+				require.Equal(t, time.Unix(42, 0), ts)
+			},
+		},
+		{
+			timestamp: "command_start",
+			check: func(t *testing.T, before, ts time.Time) {
+				require.False(t, ts.Before(before))
+				require.True(t, ts.Before(before.Add(50*time.Millisecond)))
+			},
+		},
+		{
+			timestamp: "now",
+			check: func(t *testing.T, before, ts time.Time) {
+				require.False(t, ts.Before(before.Add(50*time.Millisecond)))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timestamp, func(t *testing.T) {
+			parser, _ := parsers.NewParser(&parsers.Config{
+				DataFormat: "influx",
+			})
+			e := NewExec()
+			e.runner = slowRunner{out: []byte("cpu value=1 42000000000\n"), delay: 50 * time.Millisecond}
+			e.SetParser(parser)
+			e.Commands = []string{"slow"}
+			e.Timestamp = tt.timestamp
+			require.NoError(t, e.Init())
+
+			var acc testutil.Accumulator
+			before := time.Now()
+			require.NoError(t, acc.GatherError(e.Gather))
+			require.Len(t, acc.Metrics, 1)
+			tt.check(t, before, acc.Metrics[0].Time)
+		})
+	}
+}
+
+func TestExecInvalidTimestamp(t *testing.T) {
+	e := NewExec()
+	e.Commands = []string{"foo"}
+	e.Timestamp = "later"
+	require.Error(t, e.Init())
+}
+
+// commandRunner dispatches to a runner per command.
+type commandRunner map[string]Runner
+
+func (r commandRunner) Run(ctx context.Context, spec CommandSpec) (Result, error) {
+	return r[spec.Command].Run(ctx, spec)
+}
+
+func TestExecGatherTimeout(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	e := NewExec()
+	e.runner = commandRunner{
+		"fast": slowRunner{out: []byte("fast value=1\n")},
+		"slow": slowRunner{out: []byte("slow value=1\n"), delay: 300 * time.Millisecond},
+	}
+	e.SetParser(parser)
+	e.Commands = []string{"fast", "slow"}
+	e.GatherTimeout = internal.Duration{Duration: 100 * time.Millisecond}
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	start := time.Now()
+	require.NoError(t, e.Gather(&acc))
+	require.True(t, time.Since(start) < 300*time.Millisecond)
+
+	// Wait for the slow command to complete, its metrics are dropped.
+	time.Sleep(400 * time.Millisecond)
+	require.True(t, acc.HasMeasurement("fast"))
+	require.False(t, acc.HasMeasurement("slow"))
+	require.Len(t, acc.Errors, 1)
+	require.EqualError(t, acc.Errors[0], "exec: command 'slow' did not complete within the gather timeout")
+}
+
+// countRunner counts the runs of each command.
+type countRunner struct {
+	sync.Mutex
+	runs map[string]int
+}
+
+func (r *countRunner) Run(_ context.Context, spec CommandSpec) (Result, error) {
+	r.Lock()
+	defer r.Unlock()
+	r.runs[spec.Command]++
+	return Result{}, nil
+}
+
+func (r *countRunner) count(command string) int {
+	r.Lock()
+	defer r.Unlock()
+	return r.runs[command]
+}
+
+func TestExecEntryInterval(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	runner := &countRunner{runs: make(map[string]int)}
+	e := NewExec()
+	e.runner = runner
+	e.SetParser(parser)
+	e.Commands = []string{"fast"}
+	e.Entries = []*Entry{
+		{Command: "slow", Interval: internal.Duration{Duration: time.Hour}},
+	}
+	require.NoError(t, e.Init())
+
+	for i := 0; i < 3; i++ {
+		var acc testutil.Accumulator
+		require.NoError(t, acc.GatherError(e.Gather))
+		// The state of the commands is kept when refreshing the plan.
+		require.NoError(t, e.Refresh())
+	}
+	require.Equal(t, 3, runner.count("fast"))
+	require.Equal(t, 1, runner.count("slow"))
+}
+
+func TestExecEntrySchedule(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	runner := &countRunner{runs: make(map[string]int)}
+	e := NewExec()
+	e.runner = runner
+	e.SetParser(parser)
+	e.Entries = []*Entry{
+		{Command: "nightly", Schedule: "0 2 * * *"},
+	}
+	require.NoError(t, e.Init())
+
+	// Not run before the first time on the schedule.
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(e.Gather))
+	require.Equal(t, 0, runner.count("nightly"))
+
+	next := e.plan[0].next
+	require.Equal(t, 2, next.Hour())
+	require.Equal(t, 0, next.Minute())
+
+	// Run once when the time is reached, the state is kept when refreshing.
+	e.plan[0].next = time.Now().Add(-time.Minute)
+	require.NoError(t, e.Refresh())
+	for i := 0; i < 2; i++ {
+		require.NoError(t, acc.GatherError(e.Gather))
+	}
+	require.Equal(t, 1, runner.count("nightly"))
+	require.True(t, e.plan[0].next.After(time.Now()))
+}
+
+func TestExecEntryInvalidSchedule(t *testing.T) {
+	for _, entry := range []*Entry{
+		{Command: "foo", Schedule: "0 2 * *"},
+		{Command: "foo", Schedule: "0 0 30 2 *"},
+		{Command: "foo", Schedule: "0 2 * * *", Interval: internal.Duration{Duration: time.Hour}},
+	} {
+		e := NewExec()
+		e.Entries = []*Entry{entry}
+		require.Error(t, e.Init())
+	}
+}
+
+func TestExecEntryRunOnce(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	runner := &countRunner{runs: make(map[string]int)}
+	e := NewExec()
+	e.runner = runner
+	e.SetParser(parser)
+	e.Commands = []string{"always"}
+	e.Entries = []*Entry{
+		{Command: "inventory", RunOnce: true},
+	}
+	require.NoError(t, e.Init())
+
+	for i := 0; i < 3; i++ {
+		var acc testutil.Accumulator
+		require.NoError(t, acc.GatherError(e.Gather))
+		// The state of the commands is kept when refreshing the plan.
+		require.NoError(t, e.Refresh())
+	}
+	require.Equal(t, 3, runner.count("always"))
+	require.Equal(t, 1, runner.count("inventory"))
+}
+
+func TestExecEntryInvalidRunOnce(t *testing.T) {
+	for _, entry := range []*Entry{
+		{Command: "foo", RunOnce: true, Schedule: "0 2 * * *"},
+		{Command: "foo", RunOnce: true, Interval: internal.Duration{Duration: time.Hour}},
+	} {
+		e := NewExec()
+		e.Entries = []*Entry{entry}
+		require.Error(t, e.Init())
+	}
+}
+
+func TestExecContentEncoding(t *testing.T) {
+	encoder, err := internal.NewGzipEncoder()
+	require.NoError(t, err)
+	out, err := encoder.Encode([]byte(lineProtocol))
+	require.NoError(t, err)
+
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	e := NewExec()
+	e.runner = newRunnerMock(out, nil, nil)
+	e.SetParser(parser)
+	e.Commands = []string{"gzipped"}
+	e.ContentEncoding = "gzip"
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(e.Gather))
+	acc.AssertContainsFields(t, "cpu", map[string]interface{}{
+		"usage_idle": float64(99),
+		"usage_busy": float64(1),
+	})
+}
+
+func TestExecContentEncodingInvalidOutput(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	e := NewExec()
+	e.runner = newRunnerMock([]byte(lineProtocol), nil, nil)
+	e.SetParser(parser)
+	e.Commands = []string{"plain"}
+	e.ContentEncoding = "gzip"
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(e.Gather))
+	require.Equal(t, 0, int(acc.NMetrics()))
+}
+
+func TestExecInvalidContentEncoding(t *testing.T) {
+	e := NewExec()
+	e.Commands = []string{"foo"}
+	e.ContentEncoding = "zstd"
+	require.Error(t, e.Init())
+}
+
+// overlapRunner records the maximum number of runs in progress at once.
+type overlapRunner struct {
+	delay time.Duration
+
+	sync.Mutex
+	runs    int
+	running int
+	max     int
+}
+
+func (r *overlapRunner) Run(_ context.Context, _ CommandSpec) (Result, error) {
+	r.Lock()
+	r.runs++
+	r.running++
+	if r.running > r.max {
+		r.max = r.running
+	}
+	r.Unlock()
+
+	time.Sleep(r.delay)
+
+	r.Lock()
+	r.running--
+	r.Unlock()
+	return Result{}, nil
+}
+
+func TestExecSerializePerCommand(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	runner := &overlapRunner{delay: 100 * time.Millisecond}
+	e := NewExec()
+	e.Log = testutil.Logger{}
+	e.runner = runner
+	e.SetParser(parser)
+	e.Commands = []string{"slow"}
+	e.GatherTimeout = internal.Duration{Duration: 20 * time.Millisecond}
+	e.SerializePerCommand = true
+	require.NoError(t, e.Init())
+
+	for i := 0; i < 3; i++ {
+		var acc testutil.Accumulator
+		require.NoError(t, e.Gather(&acc))
+	}
+
+	// The overlapping runs are queued, not dropped.
+	time.Sleep(400 * time.Millisecond)
+	runner.Lock()
+	defer runner.Unlock()
+	require.Equal(t, 3, runner.runs)
+	require.Equal(t, 1, runner.max)
+}
+
+func TestExecSkipIfRunning(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	runner := &overlapRunner{delay: 200 * time.Millisecond}
+	e := NewExec()
+	e.Log = testutil.Logger{}
+	e.runner = runner
+	e.SetParser(parser)
+	e.Commands = []string{"slow"}
+	e.GatherTimeout = internal.Duration{Duration: 20 * time.Millisecond}
+	e.SkipIfRunning = true
+	require.NoError(t, e.Init())
+	e.plan[0].skipped.Set(0)
+
+	for i := 0; i < 3; i++ {
+		var acc testutil.Accumulator
+		require.NoError(t, e.Gather(&acc))
+	}
+	require.Equal(t, int64(2), e.plan[0].skipped.Get())
+
+	// The command runs again once the previous run completed.
+	time.Sleep(300 * time.Millisecond)
+	var acc testutil.Accumulator
+	require.NoError(t, e.Gather(&acc))
+	e.Stop()
+
+	runner.Lock()
+	defer runner.Unlock()
+	require.Equal(t, 2, runner.runs)
+	require.Equal(t, 1, runner.max)
+	require.Equal(t, int64(2), e.plan[0].skipped.Get())
+}
+
+func TestExecErrorStats(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	e := NewExec()
+	e.Log = testutil.Logger{}
+	e.runner = commandRunner{
+		"timeout":   newRunnerMock(nil, nil, internal.TimeoutErr),
+		"exit":      newRunnerMock(nil, nil, errors.New("exit status 1")),
+		"garbage":   newRunnerMock([]byte("not line protocol\n"), nil, nil),
+		"slow":      slowRunner{out: []byte("slow value=1\n"), delay: 200 * time.Millisecond},
+		"succeeded": newRunnerMock([]byte(lineProtocol), nil, nil),
+	}
+	e.SetParser(parser)
+	e.Commands = []string{"timeout", "exit", "garbage", "slow", "succeeded"}
+	e.GatherTimeout = internal.Duration{Duration: 100 * time.Millisecond}
+	require.NoError(t, e.Init())
+	for _, c := range e.plan {
+		c.timeouts.Set(0)
+		c.exitErrors.Set(0)
+		c.parseErrors.Set(0)
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Gather(&acc))
+	e.Stop()
+
+	stats := make(map[string][]int64)
+	for _, c := range e.plan {
+		stats[c.command] = []int64{c.timeouts.Get(), c.exitErrors.Get(), c.parseErrors.Get()}
+	}
+	require.Equal(t, map[string][]int64{
+		"timeout":   {1, 0, 0},
+		"exit":      {0, 1, 0},
+		"garbage":   {0, 0, 1},
+		"slow":      {1, 0, 0},
+		"succeeded": {0, 0, 0},
+	}, stats)
+}
+
+// blockingRunner runs until the context is done.
+type blockingRunner struct {
+	stopped int32
+}
+
+func (r *blockingRunner) Run(ctx context.Context, _ CommandSpec) (Result, error) {
+	<-ctx.Done()
+	atomic.StoreInt32(&r.stopped, 1)
+	return Result{ExitCode: -1}, ctx.Err()
+}
+
+func TestExecStopWaitsForCommands(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	runner := &blockingRunner{}
+	e := NewExec()
+	e.Log = testutil.Logger{}
+	e.runner = runner
+	e.SetParser(parser)
+	e.Commands = []string{"forever"}
+	e.GatherTimeout = internal.Duration{Duration: 50 * time.Millisecond}
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+	require.NoError(t, e.Gather(&acc))
+	require.Equal(t, int32(0), atomic.LoadInt32(&runner.stopped))
+
+	e.Stop()
+	require.Equal(t, int32(1), atomic.LoadInt32(&runner.stopped))
+}
+
+func TestExecEntryWithoutCommand(t *testing.T) {
+	e := NewExec()
+	e.Entries = []*Entry{{NameOverride: "foo"}}
+	require.Error(t, e.Init())
+}
+
 func TestTruncate(t *testing.T) {
 	tests := []struct {
 		name string
@@ -256,12 +989,34 @@ func TestTruncate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			res := truncate(*tt.bufF())
+			res, _ := truncate(*tt.bufF(), MaxStderrBytes, false)
 			require.Equal(t, tt.expF().Bytes(), res.Bytes())
 		})
 	}
 }
 
+func TestTruncateMultiline(t *testing.T) {
+	var b bytes.Buffer
+	b.WriteString("hello world\nand all the people")
+
+	res, truncated := truncate(b, 16, true)
+	require.True(t, truncated)
+	require.Equal(t, "hello world\nand ...", res.String())
+
+	var short bytes.Buffer
+	short.WriteString("hello\nworld")
+	res, truncated = truncate(short, 16, true)
+	require.False(t, truncated)
+	require.Equal(t, "hello\nworld", res.String())
+}
+
+func TestExecInvalidStderr(t *testing.T) {
+	e := NewExec()
+	e.Commands = []string{"foo"}
+	e.Stderr = "stdout"
+	require.Error(t, e.Init())
+}
+
 func TestRemoveCarriageReturns(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		// Test that all carriage returns are removed
@@ -0,0 +1,98 @@
+// +build !windows
+
+package exec
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// runTimeout runs the command in its own process group, so that on timeout
+// or when the context is done the whole group is terminated and no children
+// of a shell are left behind.  The group is sent SIGTERM first and SIGKILL
+// once the grace period expires.
+func runTimeout(ctx context.Context, c *exec.Cmd, spec CommandSpec) error {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := c.Start(); err != nil {
+		return err
+	}
+	pgid := -c.Process.Pid
+
+	// The limits are set once the command is started, so they do not apply
+	// to processes it started right away.
+	if err := setLimits(c.Process.Pid, spec.CPULimit, spec.MemoryLimit); err != nil {
+		syscall.Kill(pgid, syscall.SIGKILL)
+		c.Wait()
+		return err
+	}
+
+	done := make(chan struct{})
+	termSent := make(chan struct{})
+	go func() {
+		timer := time.NewTimer(spec.Timeout)
+		defer timer.Stop()
+		select {
+		case <-done:
+			return
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+
+		close(termSent)
+		if err := syscall.Kill(pgid, syscall.SIGTERM); err != nil && err != syscall.ESRCH {
+			log.Printf("E! [inputs.exec] Error terminating process group: %s", err)
+		}
+
+		timer.Reset(spec.KillGrace)
+		select {
+		case <-done:
+		case <-timer.C:
+			if err := syscall.Kill(pgid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+				log.Printf("E! [inputs.exec] Error killing process group: %s", err)
+			}
+		}
+	}()
+
+	err := c.Wait()
+	close(done)
+
+	// If the process exited without error treat it as success.  This allows a
+	// process to do a clean shutdown on signal.
+	if err == nil {
+		return nil
+	}
+
+	select {
+	case <-termSent:
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return internal.TimeoutErr
+	default:
+		return err
+	}
+}
+
+// setUsage sets the memory and I/O usage of the finished process.
+func setUsage(r *Result, state *os.ProcessState) {
+	usage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return
+	}
+
+	// The maximum resident set size is in bytes on macOS, in kilobytes on
+	// the other systems.
+	r.MaxRSS = int64(usage.Maxrss)
+	if runtime.GOOS != "darwin" {
+		r.MaxRSS *= 1024
+	}
+	r.BlocksRead = int64(usage.Inblock)
+	r.BlocksWritten = int64(usage.Oublock)
+}
@@ -0,0 +1,369 @@
+// +build !windows
+
+package exec
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTimeoutKillsProcessGroup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exec")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	pidfile := filepath.Join(dir, "pid")
+
+	command := "sh -c 'sleep 30 & echo $! > " + pidfile + "; wait'"
+	start := time.Now()
+	res, err := CommandRunner{}.Run(context.Background(), CommandSpec{
+		Command: command,
+		Timeout: 100 * time.Millisecond,
+	})
+	require.Equal(t, internal.TimeoutErr, err)
+	require.Equal(t, -1, res.ExitCode)
+	require.True(t, time.Since(start) < internal.KillGrace)
+
+	buf, err := ioutil.ReadFile(pidfile)
+	require.NoError(t, err)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf)))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return !running(pid)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRunContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := CommandRunner{}.Run(ctx, CommandSpec{
+		Command: "sleep 30",
+		Timeout: time.Minute,
+	})
+	require.Equal(t, context.Canceled, err)
+	require.True(t, time.Since(start) < internal.KillGrace)
+}
+
+func TestRunKillGrace(t *testing.T) {
+	// The command and its children ignore SIGTERM, so they are only
+	// stopped by the SIGKILL after the grace period.
+	command := `sh -c 'trap "" TERM; echo started; sleep 30'`
+	start := time.Now()
+	res, err := CommandRunner{}.Run(context.Background(), CommandSpec{
+		Command:   command,
+		Timeout:   100 * time.Millisecond,
+		KillGrace: 300 * time.Millisecond,
+	})
+	elapsed := time.Since(start)
+	require.Equal(t, internal.TimeoutErr, err)
+	require.Equal(t, "started\n", string(res.Stdout))
+	require.True(t, elapsed >= 400*time.Millisecond, elapsed)
+	require.True(t, elapsed < internal.KillGrace, elapsed)
+}
+
+func TestRunKillGraceCleanShutdown(t *testing.T) {
+	// The command flushes its output when it is sent SIGTERM.
+	command := `sh -c 'trap "echo flushed; exit 0" TERM; sleep 30 & wait'`
+	res, err := CommandRunner{}.Run(context.Background(), CommandSpec{
+		Command:   command,
+		Timeout:   100 * time.Millisecond,
+		KillGrace: time.Minute,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "flushed\n", string(res.Stdout))
+}
+
+// running returns true if the process exists and is not a zombie.
+func running(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+	stat, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+	fields := strings.Fields(string(stat))
+	return len(fields) < 3 || fields[2] != "Z"
+}
+
+func TestExecStdin(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	e := NewExec()
+	e.Log = testutil.Logger{}
+	e.Commands = []string{"cat"}
+	e.Stdin = "cpu value=1 0\n"
+	e.Entries = []*Entry{{Command: "cat -", Stdin: "mem value=2 0\n"}}
+	e.SetParser(parser)
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(e.Gather))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": float64(1)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("mem",
+			map[string]string{},
+			map[string]interface{}{"value": float64(2)},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.SortMetrics())
+}
+
+func TestRunMaxOutputSize(t *testing.T) {
+	res, err := CommandRunner{}.Run(context.Background(), CommandSpec{
+		Command:       "sh -c 'echo line1; echo line2; echo line3'",
+		Timeout:       5 * time.Second,
+		MaxOutputSize: 8,
+	})
+	require.NoError(t, err)
+	require.True(t, res.OutputTruncated)
+	require.Equal(t, "line1\nli", string(res.Stdout))
+}
+
+func TestExecMaxOutputSize(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	e := NewExec()
+	e.Log = testutil.Logger{}
+	e.Commands = []string{"sh -c 'echo cpu value=1 0; echo cpu value=2 0'"}
+	e.MaxOutputSize = internal.Size{Size: 20}
+	e.ExecutionMetrics = true
+	e.SetParser(parser)
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(e.Gather))
+
+	// The partial second line is not parsed.
+	m, ok := acc.Get("cpu")
+	require.True(t, ok)
+	require.Equal(t, float64(1), m.Fields["value"])
+	require.Equal(t, 2, int(acc.NMetrics()))
+
+	m, ok = acc.Get("exec_execution")
+	require.True(t, ok)
+	require.Equal(t, true, m.Fields["output_truncated"])
+}
+
+func TestExecStop(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	e := NewExec()
+	e.Log = testutil.Logger{}
+	e.Commands = []string{
+		`sh -c 'trap "echo cpu value=1 0; exit 0" TERM; sleep 30 & wait'`,
+		"sleep 30",
+	}
+	e.SetParser(parser)
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+	done := make(chan error)
+	go func() {
+		done <- e.Gather(&acc)
+	}()
+
+	// Give the commands time to start.
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	e.Stop()
+	require.True(t, time.Since(start) < time.Second)
+	require.NoError(t, <-done)
+
+	// The output of the command exiting on SIGTERM is kept, the terminated
+	// command is not reported as an error.
+	require.Empty(t, acc.Errors)
+	acc.AssertContainsFields(t, "cpu", map[string]interface{}{"value": float64(1)})
+}
+
+func TestExecSuccessExitCodes(t *testing.T) {
+	parser, _ := parsers.NewValueParser("metric", "integer", nil)
+	e := NewExec()
+	e.Log = testutil.Logger{}
+	e.Commands = []string{"sh -c 'echo 42; exit 2'"}
+	e.SuccessExitCodes = []int{0, 2}
+	e.SetParser(parser)
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(e.Gather))
+	acc.AssertContainsFields(t, "metric", map[string]interface{}{"value": int64(42)})
+}
+
+func TestExecUnexpectedExitCode(t *testing.T) {
+	parser, _ := parsers.NewValueParser("metric", "integer", nil)
+	e := NewExec()
+	e.Log = testutil.Logger{}
+	e.Commands = []string{"sh -c 'echo 42; exit 3'"}
+	e.SuccessExitCodes = []int{0, 2}
+	e.SetParser(parser)
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(e.Gather))
+	require.Equal(t, 0, int(acc.NMetrics()))
+}
+
+func TestExecIgnoreError(t *testing.T) {
+	parser, _ := parsers.NewValueParser("metric", "integer", nil)
+	e := NewExec()
+	e.Log = testutil.Logger{}
+	e.Commands = []string{"sh -c 'echo 42; exit 1'"}
+	e.IgnoreError = true
+	e.SetParser(parser)
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(e.Gather))
+	acc.AssertContainsFields(t, "metric", map[string]interface{}{"value": int64(42)})
+}
+
+func TestExecExecutionMetrics(t *testing.T) {
+	parser, _ := parsers.NewValueParser("metric", "integer", nil)
+	e := NewExec()
+	e.Log = testutil.Logger{}
+	e.Commands = []string{"sh -c 'echo 42; echo oops >&2; echo again >&2; exit 2'"}
+	e.SuccessExitCodes = []int{0, 2}
+	e.ExecutionMetrics = true
+	e.SetParser(parser)
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(e.Gather))
+	require.True(t, acc.HasMeasurement("metric"))
+
+	m, ok := acc.Get("exec_execution")
+	require.True(t, ok)
+	require.Equal(t, map[string]string{"command": e.Commands[0]}, m.Tags)
+	require.Equal(t, 2, m.Fields["exit_code"])
+	require.Equal(t, true, m.Fields["stderr_truncated"])
+	require.Contains(t, m.Fields, "duration_ms")
+	require.Contains(t, m.Fields, "user_time_ms")
+	require.Contains(t, m.Fields, "system_time_ms")
+	require.True(t, m.Fields["max_rss_bytes"].(int64) > 0)
+	require.Contains(t, m.Fields, "blocks_read")
+	require.Contains(t, m.Fields, "blocks_written")
+}
+
+func TestExecEvents(t *testing.T) {
+	parser, _ := parsers.NewValueParser("metric", "integer", nil)
+	e := NewExec()
+	e.Log = testutil.Logger{}
+	e.Commands = []string{"sh -c 'echo 42; echo oops >&2; echo again >&2; exit 2'"}
+	e.SuccessExitCodes = []int{0, 2}
+	e.EmitEvents = true
+	e.Stderr = "log"
+	e.SetParser(parser)
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(e.Gather))
+	require.True(t, acc.HasMeasurement("metric"))
+	require.False(t, acc.HasMeasurement("exec_execution"))
+
+	m, ok := acc.Get("exec_event")
+	require.True(t, ok)
+	require.Equal(t, map[string]string{"command": e.Commands[0]}, m.Tags)
+	require.Equal(t, 2, m.Fields["exit_code"])
+	require.Equal(t, "oops...", m.Fields["stderr"])
+	start := m.Fields["start_time"].(int64)
+	end := m.Fields["end_time"].(int64)
+	require.True(t, start <= end)
+	require.Equal(t, end, m.Time.UnixNano())
+}
+
+func TestExecStderr(t *testing.T) {
+	command := "sh -c 'echo 42; echo oops >&2; echo again >&2; exit 1'"
+	tests := []struct {
+		name     string
+		stderr   string
+		errors   []string
+		expected []telegraf.Metric
+	}{
+		{
+			name:   "error message",
+			errors: []string{"exec: exit status 1 for command '" + command + "': oops..."},
+		},
+		{
+			name:   "ignore",
+			stderr: "ignore",
+			errors: []string{"exec: exit status 1 for command '" + command + "'"},
+		},
+		{
+			name:   "metric",
+			stderr: "metric",
+			errors: []string{"exec: exit status 1 for command '" + command + "'"},
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"exec_stderr",
+					map[string]string{"command": command},
+					map[string]interface{}{"stderr": "oops\nagain\n"},
+					time.Unix(0, 0),
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, _ := parsers.NewValueParser("metric", "integer", nil)
+			e := NewExec()
+			e.Log = testutil.Logger{}
+			e.Commands = []string{command}
+			e.Stderr = tt.stderr
+			e.SetParser(parser)
+			require.NoError(t, e.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, e.Gather(&acc))
+
+			var errors []string
+			for _, err := range acc.Errors {
+				errors = append(errors, err.Error())
+			}
+			require.Equal(t, tt.errors, errors)
+			testutil.RequireMetricsEqual(t, tt.expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestExecStderrMerge(t *testing.T) {
+	parser, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "influx",
+	})
+	e := NewExec()
+	e.Log = testutil.Logger{}
+	e.Commands = []string{"sh -c 'echo cpu value=1 >&2; echo cpu value=2'"}
+	e.Stderr = "merge"
+	e.SetParser(parser)
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(e.Gather))
+	require.Equal(t, 2, int(acc.NMetrics()))
+}
@@ -0,0 +1,100 @@
+// +build windows
+
+package exec
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"golang.org/x/sys/windows"
+)
+
+// runTimeout runs the command in a job object, so that on timeout or when the
+// context is done the command and the processes it started are killed.  There
+// is no SIGTERM on Windows, so the grace period is not used.
+func runTimeout(ctx context.Context, c *exec.Cmd, spec CommandSpec) error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	// Processes started before the command is assigned to the job are not
+	// part of it, in that case only the command is killed.
+	job, err := assignJob(c.Process.Pid)
+	if err != nil {
+		log.Printf("D! [inputs.exec] Unable to assign command to a job object: %s", err)
+	} else {
+		defer windows.CloseHandle(job)
+	}
+
+	done := make(chan struct{})
+	killed := make(chan struct{})
+	go func() {
+		timer := time.NewTimer(spec.Timeout)
+		defer timer.Stop()
+		select {
+		case <-done:
+			return
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+
+		close(killed)
+		var err error
+		if job != 0 {
+			err = windows.TerminateJobObject(job, 1)
+		} else {
+			err = c.Process.Kill()
+		}
+		if err != nil {
+			log.Printf("E! [inputs.exec] Error killing process: %s", err)
+		}
+	}()
+
+	err = c.Wait()
+	close(done)
+
+	// If the process exited without error treat it as success.
+	if err == nil {
+		return nil
+	}
+
+	select {
+	case <-killed:
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return internal.TimeoutErr
+	default:
+		return err
+	}
+}
+
+// assignJob creates a job object with the process.
+func assignJob(pid int) (windows.Handle, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	process, err := windows.OpenProcess(windows.PROCESS_TERMINATE|windows.PROCESS_SET_QUOTA, false, uint32(pid))
+	if err != nil {
+		windows.CloseHandle(job)
+		return 0, err
+	}
+	defer windows.CloseHandle(process)
+
+	if err := windows.AssignProcessToJobObject(job, process); err != nil {
+		windows.CloseHandle(job)
+		return 0, err
+	}
+	return job, nil
+}
+
+// setUsage sets the memory and I/O usage of the finished process, which is
+// not available on Windows.
+func setUsage(r *Result, state *os.ProcessState) {
+}
@@ -0,0 +1,56 @@
+package exec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// A Wednesday.
+	now := time.Date(2020, 4, 15, 10, 30, 20, 0, time.UTC)
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2020, 4, 15, 10, 31, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2020, 4, 16, 2, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2020, 4, 15, 10, 45, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2020, 4, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", time.Date(2020, 4, 15, 11, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2020, 4, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 1,7 *", time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted.
+		{"0 0 20 * 5", time.Date(2020, 4, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := parseSchedule(tt.expr)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, s.next(now))
+		})
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-a * * * *",
+	} {
+		_, err := parseSchedule(expr)
+		require.Error(t, err, expr)
+	}
+}
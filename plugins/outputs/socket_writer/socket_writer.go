@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
@@ -14,14 +16,22 @@ import (
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
+// Delay of the first reconnect attempt when max_reconnect_interval is set.
+const minReconnectInterval = time.Second
+
 type SocketWriter struct {
-	Address         string
-	KeepAlivePeriod *internal.Duration
+	Address              string
+	KeepAlivePeriod      *internal.Duration
+	MaxReconnectInterval internal.Duration
+	Framing              string
 	tlsint.ClientConfig
 
 	serializers.Serializer
 
 	net.Conn
+
+	reconnectInterval time.Duration
+	nextConnect       time.Time
 }
 
 func (sw *SocketWriter) Description() string {
@@ -55,6 +65,17 @@ func (sw *SocketWriter) SampleConfig() string {
   ## Defaults to the OS configuration.
   # keep_alive_period = "5m"
 
+  ## Maximum time between reconnect attempts.  After a failed attempt the
+  ## time until the next attempt starts at 1s and doubles up to this value,
+  ## writes are failed in between.  By default an attempt is made on every
+  ## write.
+  # max_reconnect_interval = "1m"
+
+  ## Framing of each serialized metric.  Set to "octet-counting" to prefix
+  ## each metric with its length in bytes and a space, as in RFC 6587, for
+  ## readers of stream sockets that do not split on newlines.
+  # framing = ""
+
   ## Data format to generate.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -67,6 +88,15 @@ func (sw *SocketWriter) SetSerializer(s serializers.Serializer) {
 	sw.Serializer = s
 }
 
+func (sw *SocketWriter) Init() error {
+	switch sw.Framing {
+	case "", "octet-counting":
+	default:
+		return fmt.Errorf("unsupported framing %q", sw.Framing)
+	}
+	return nil
+}
+
 func (sw *SocketWriter) Connect() error {
 	spl := strings.SplitN(sw.Address, "://", 2)
 	if len(spl) != 2 {
@@ -119,7 +149,7 @@ func (sw *SocketWriter) setKeepAlive(c net.Conn) error {
 func (sw *SocketWriter) Write(metrics []telegraf.Metric) error {
 	if sw.Conn == nil {
 		// previous write failed with permanent error and socket was closed.
-		if err := sw.Connect(); err != nil {
+		if err := sw.reconnect(); err != nil {
 			return err
 		}
 	}
@@ -130,6 +160,9 @@ func (sw *SocketWriter) Write(metrics []telegraf.Metric) error {
 			log.Printf("D! [outputs.socket_writer] Could not serialize metric: %v", err)
 			continue
 		}
+		if sw.Framing == "octet-counting" {
+			bs = append([]byte(strconv.Itoa(len(bs))+" "), bs...)
+		}
 		if _, err := sw.Conn.Write(bs); err != nil {
 			//TODO log & keep going with remaining strings
 			if err, ok := err.(net.Error); !ok || !err.Temporary() {
@@ -145,6 +178,34 @@ func (sw *SocketWriter) Write(metrics []telegraf.Metric) error {
 	return nil
 }
 
+// reconnect connects to the address, unless the time until the next attempt
+// after a failed one has not passed yet.
+func (sw *SocketWriter) reconnect() error {
+	if sw.MaxReconnectInterval.Duration <= 0 {
+		return sw.Connect()
+	}
+
+	if time.Now().Before(sw.nextConnect) {
+		return fmt.Errorf("waiting until %s to reconnect", sw.nextConnect.Format(time.RFC3339))
+	}
+
+	if err := sw.Connect(); err != nil {
+		if sw.reconnectInterval == 0 {
+			sw.reconnectInterval = minReconnectInterval
+		} else {
+			sw.reconnectInterval *= 2
+		}
+		if sw.reconnectInterval > sw.MaxReconnectInterval.Duration {
+			sw.reconnectInterval = sw.MaxReconnectInterval.Duration
+		}
+		sw.nextConnect = time.Now().Add(sw.reconnectInterval)
+		return err
+	}
+
+	sw.reconnectInterval = 0
+	return nil
+}
+
 // Close closes the connection. Noop if already closed.
 func (sw *SocketWriter) Close() error {
 	if sw.Conn == nil {
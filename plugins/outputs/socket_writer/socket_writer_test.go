@@ -7,8 +7,10 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
@@ -195,3 +197,77 @@ func TestSocketWriter_Write_reconnect(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, string(mbsout), string(buf[:n]))
 }
+
+func TestSocketWriter_Framing(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+	sock := filepath.Join(tmpdir, "sw.TestSocketWriter_Framing.sock")
+
+	listener, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+
+	sw := newSocketWriter()
+	sw.Address = "unix://" + sock
+	sw.Framing = "octet-counting"
+	require.NoError(t, sw.Init())
+
+	err = sw.Connect()
+	require.NoError(t, err)
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+
+	metrics := []telegraf.Metric{testutil.TestMetric(1, "test")}
+	mbsout, _ := sw.Serialize(metrics[0])
+	require.NoError(t, sw.Write(metrics))
+	require.NoError(t, sw.Close())
+
+	buf, err := ioutil.ReadAll(lconn)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(len(mbsout))+" "+string(mbsout), string(buf))
+}
+
+func TestSocketWriter_Init_err(t *testing.T) {
+	sw := newSocketWriter()
+	sw.Framing = "length-prefix"
+	require.Error(t, sw.Init())
+}
+
+func TestSocketWriter_Write_backoff(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+	sock := filepath.Join(tmpdir, "sw.TestSocketWriter_Write_backoff.sock")
+
+	sw := newSocketWriter()
+	sw.Address = "unix://" + sock
+	sw.MaxReconnectInterval.Duration = 3 * time.Second
+
+	metrics := []telegraf.Metric{testutil.TestMetric(1, "test")}
+	require.Error(t, sw.Write(metrics))
+	require.Equal(t, time.Second, sw.reconnectInterval)
+
+	// No attempt is made until the interval has passed.
+	listener, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	defer listener.Close()
+	require.Error(t, sw.Write(metrics))
+	require.Nil(t, sw.Conn)
+
+	// The interval doubles up to the maximum.
+	listener.Close()
+	for _, expected := range []time.Duration{2 * time.Second, 3 * time.Second} {
+		sw.nextConnect = time.Time{}
+		require.Error(t, sw.Write(metrics))
+		require.Equal(t, expected, sw.reconnectInterval)
+	}
+
+	listener, err = net.Listen("unix", sock)
+	require.NoError(t, err)
+	sw.nextConnect = time.Time{}
+	require.NoError(t, sw.Write(metrics))
+	require.Equal(t, time.Duration(0), sw.reconnectInterval)
+	require.NoError(t, sw.Close())
+	require.NoError(t, listener.Close())
+}
@@ -4,17 +4,26 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
 )
 
 const (
 	maxErrorBufferSize = 1024
 )
 
+// Handling of lines that fail to parse, see Parser.OnParseError.
+const (
+	OnParseErrorError     = "error"
+	OnParseErrorDropLine  = "drop_line"
+	OnParseErrorDropBatch = "drop_batch"
+)
+
 var (
 	ErrNoMetric = errors.New("no metric in line")
 )
@@ -48,6 +57,14 @@ func (e *ParseError) Error() string {
 type Parser struct {
 	DefaultTags map[string]string
 
+	// OnParseError selects how lines that fail to parse are handled: "error"
+	// (the default) rejects the input with an error, "drop_line" skips the
+	// line and "drop_batch" silently discards the whole input.
+	OnParseError string
+
+	// MalformedLines, when set, counts the lines that failed to parse.
+	MalformedLines selfstat.Stat
+
 	sync.Mutex
 	*machine
 	handler *MetricHandler
@@ -86,7 +103,7 @@ func (p *Parser) Parse(input []byte) ([]telegraf.Metric, error) {
 		}
 
 		if err != nil {
-			return nil, &ParseError{
+			perr := &ParseError{
 				Offset:     p.machine.Position(),
 				LineOffset: p.machine.LineOffset(),
 				LineNumber: p.machine.LineNumber(),
@@ -94,6 +111,20 @@ func (p *Parser) Parse(input []byte) ([]telegraf.Metric, error) {
 				msg:        err.Error(),
 				buf:        string(input),
 			}
+			if p.MalformedLines != nil {
+				p.MalformedLines.Incr(1)
+			}
+
+			switch p.OnParseError {
+			case OnParseErrorDropLine:
+				log.Printf("D! [parser.influx] Dropped line: %v", perr)
+				continue
+			case OnParseErrorDropBatch:
+				log.Printf("D! [parser.influx] Dropped %d bytes of input: %v", len(input), perr)
+				return []telegraf.Metric{}, nil
+			default:
+				return nil, perr
+			}
 		}
 
 		metric, err := p.handler.Metric()
@@ -9,6 +9,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -809,6 +810,63 @@ func TestParserErrorString(t *testing.T) {
 	}
 }
 
+func TestParserOnParseError(t *testing.T) {
+	var ptests = []struct {
+		name         string
+		onParseError string
+		input        []byte
+		metrics      int
+		malformed    int64
+		err          bool
+	}{
+		{
+			name:         "error",
+			onParseError: OnParseErrorError,
+			input:        []byte("cpu value=42\ncpu value=invalid\ncpu value=43"),
+			malformed:    1,
+			err:          true,
+		},
+		{
+			name:         "drop line",
+			onParseError: OnParseErrorDropLine,
+			input:        []byte("cpu value=42\ncpu value=invalid\ncpu value=43\ncpu value=\n"),
+			metrics:      2,
+			malformed:    2,
+		},
+		{
+			name:         "drop batch",
+			onParseError: OnParseErrorDropBatch,
+			input:        []byte("cpu value=42\ncpu value=invalid\ncpu value=43"),
+			malformed:    1,
+		},
+		{
+			name:         "valid input",
+			onParseError: OnParseErrorDropBatch,
+			input:        []byte("cpu value=42\ncpu value=43"),
+			metrics:      2,
+		},
+	}
+
+	for _, tt := range ptests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewMetricHandler()
+			parser := NewParser(handler)
+			parser.OnParseError = tt.onParseError
+			parser.MalformedLines = selfstat.Register("test", "malformed_lines",
+				map[string]string{"test": t.Name()})
+
+			metrics, err := parser.Parse(tt.input)
+			if tt.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Len(t, metrics, tt.metrics)
+			require.Equal(t, tt.malformed, parser.MalformedLines.Get())
+		})
+	}
+}
+
 func TestStreamParserErrorString(t *testing.T) {
 	var ptests = []struct {
 		name  string
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"strconv"
 	"time"
@@ -32,6 +33,7 @@ type Config struct {
 	Timezone     string
 	DefaultTags  map[string]string
 	Strict       bool
+	Stream       bool
 }
 
 type Parser struct {
@@ -45,6 +47,7 @@ type Parser struct {
 	timezone     string
 	defaultTags  map[string]string
 	strict       bool
+	stream       bool
 }
 
 func New(config *Config) (*Parser, error) {
@@ -64,6 +67,7 @@ func New(config *Config) (*Parser, error) {
 		timezone:     config.Timezone,
 		defaultTags:  config.DefaultTags,
 		strict:       config.Strict,
+		stream:       config.Stream,
 	}, nil
 }
 
@@ -185,6 +189,34 @@ func (p *Parser) switchFieldToTag(tags map[string]string, fields map[string]inte
 }
 
 func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	if !p.stream {
+		return p.parseDocument(buf)
+	}
+
+	// Each of the concatenated or newline delimited documents is parsed on
+	// its own.
+	results := make([]telegraf.Metric, 0)
+	decoder := json.NewDecoder(bytes.NewReader(bytes.TrimPrefix(buf, utf8BOM)))
+	for {
+		var doc json.RawMessage
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		metrics, err := p.parseDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, metrics...)
+	}
+	return results, nil
+}
+
+func (p *Parser) parseDocument(buf []byte) ([]telegraf.Metric, error) {
 	if p.query != "" {
 		result := gjson.GetBytes(buf, p.query)
 		buf = []byte(result.Raw)
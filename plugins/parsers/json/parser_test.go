@@ -936,3 +936,155 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestParseStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *Config
+		input    []byte
+		expected []telegraf.Metric
+	}{
+		{
+			name: "newline delimited",
+			config: &Config{
+				MetricName: "json",
+				TimeKey:    "timestamp",
+				TimeFormat: "unix",
+				Stream:     true,
+			},
+			input: []byte(`{"value": 42, "timestamp": 1541183052}
+{"value": 43, "timestamp": 1541183053}
+`),
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"json",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(1541183052, 0),
+				),
+				testutil.MustMetric(
+					"json",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 43.0,
+					},
+					time.Unix(1541183053, 0),
+				),
+			},
+		},
+		{
+			name: "concatenated objects and arrays",
+			config: &Config{
+				MetricName: "json",
+				Stream:     true,
+			},
+			input: []byte(`{"value": 1}{"value": 2} [{"value": 3}, {"value": 4}]`),
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"json",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 1.0,
+					},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric(
+					"json",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 2.0,
+					},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric(
+					"json",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 3.0,
+					},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric(
+					"json",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 4.0,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "query is applied to each document",
+			config: &Config{
+				MetricName: "json",
+				Query:      "data",
+				Stream:     true,
+			},
+			input: []byte(`{"data": {"value": 1}}
+{"data": {"value": 2}}`),
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"json",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 1.0,
+					},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric(
+					"json",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 2.0,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "empty input",
+			config: &Config{
+				MetricName: "json",
+				Stream:     true,
+			},
+			input:    []byte("\n"),
+			expected: []telegraf.Metric{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := New(tt.config)
+			require.NoError(t, err)
+
+			actual, err := parser.Parse(tt.input)
+			require.NoError(t, err)
+
+			testutil.RequireMetricsEqual(t, tt.expected, actual, testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestParseStreamInvalid(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "json",
+		Stream:     true,
+	})
+	require.NoError(t, err)
+
+	_, err = parser.Parse([]byte(`{"value": 1}{"value": `))
+	require.Error(t, err)
+}
+
+func TestParseWithoutStream(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "json",
+	})
+	require.NoError(t, err)
+
+	_, err = parser.Parse([]byte(`{"value": 1}
+{"value": 2}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,57 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/influxdata/telegraf/plugins/common/pathparser"
+)
+
+var (
+	ErrNoMetric = pathparser.ErrNoMetric
+)
+
+type Config = pathparser.Config
+
+// Parser extracts fields and tags from JSON documents using JSONPath
+// expressions.
+type Parser = pathparser.Parser
+
+var format = &pathparser.Format{
+	Name:         "jsonpath",
+	DefaultQuery: "$",
+	Compile: func(expr string) (pathparser.Path, error) {
+		path, err := Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		return path, nil
+	},
+	Parse: func(buf []byte) (interface{}, error) {
+		var root interface{}
+		err := json.Unmarshal(buf, &root)
+		return root, err
+	},
+	Tag: func(v interface{}) (string, bool) {
+		switch v := v.(type) {
+		case string:
+			return v, true
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		case bool:
+			return strconv.FormatBool(v), true
+		}
+		return "", false
+	},
+	Field: func(v interface{}) (interface{}, bool) {
+		switch v.(type) {
+		case string, float64, bool:
+			return v, true
+		}
+		return nil, false
+	},
+}
+
+func New(config *Config) (*Parser, error) {
+	return pathparser.New(format, config)
+}
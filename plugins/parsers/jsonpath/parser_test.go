@@ -0,0 +1,188 @@
+package jsonpath
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const pools = `
+{
+  "cluster": "east",
+  "pools": [
+    {"name": "web", "stats": {"active": 3, "idle": 7}, "enabled": true},
+    {"name": "db", "stats": {"active": 1, "idle": 0}, "enabled": false}
+  ]
+}
+`
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{expr: "$"},
+		{expr: "@.name"},
+		{expr: "$.pools[*].stats.active"},
+		{expr: "$['pools'][-1]"},
+		{expr: "$.pools.*"},
+		{expr: "", wantErr: true},
+		{expr: "pools", wantErr: true},
+		{expr: "$.pools[", wantErr: true},
+		{expr: "$.pools[x]", wantErr: true},
+		{expr: "$['pools]", wantErr: true},
+		{expr: "$..pools", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Compile(tt.expr)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseArrayIteration(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "pool",
+		Query:      "$.pools[*]",
+		Fields: map[string]string{
+			"active":  "@.stats.active",
+			"idle":    "@.stats.idle",
+			"enabled": "@.enabled",
+		},
+		Tags: map[string]string{
+			"name":    "@.name",
+			"cluster": "$.cluster",
+		},
+	})
+	require.NoError(t, err)
+	parser.SetTimeFunc(func() time.Time { return time.Unix(42, 0) })
+
+	metrics, err := parser.Parse([]byte(pools))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"pool",
+			map[string]string{
+				"name":    "web",
+				"cluster": "east",
+			},
+			map[string]interface{}{
+				"active":  float64(3),
+				"idle":    float64(7),
+				"enabled": true,
+			},
+			time.Unix(42, 0),
+		),
+		testutil.MustMetric(
+			"pool",
+			map[string]string{
+				"name":    "db",
+				"cluster": "east",
+			},
+			map[string]interface{}{
+				"active":  float64(1),
+				"idle":    float64(0),
+				"enabled": false,
+			},
+			time.Unix(42, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseRootDocument(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "pool",
+		Fields: map[string]string{
+			"last_active": "$.pools[-1].stats.active",
+			"first_idle":  "$['pools'][0]['stats']['idle']",
+			"missing":     "$.nothing",
+		},
+		DefaultTags: map[string]string{"host": "localhost"},
+	})
+	require.NoError(t, err)
+	parser.SetTimeFunc(func() time.Time { return time.Unix(42, 0) })
+
+	m, err := parser.ParseLine(pools)
+	require.NoError(t, err)
+
+	expected := testutil.MustMetric(
+		"pool",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{
+			"last_active": float64(1),
+			"first_idle":  float64(7),
+		},
+		time.Unix(42, 0),
+	)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{expected}, []telegraf.Metric{m})
+}
+
+func TestParseMultipleMatches(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "pool",
+		Fields: map[string]string{
+			"active": "$.pools[*].stats.active",
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = parser.Parse([]byte(pools))
+	require.Error(t, err)
+}
+
+func TestParseNoFields(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "pool",
+		Fields: map[string]string{
+			"active": "$.nothing",
+		},
+	})
+	require.NoError(t, err)
+
+	metrics, err := parser.Parse([]byte(pools))
+	require.NoError(t, err)
+	require.Len(t, metrics, 0)
+
+	_, err = parser.ParseLine(pools)
+	require.Equal(t, ErrNoMetric, err)
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New(&Config{MetricName: "pool"})
+	require.Error(t, err)
+
+	_, err = New(&Config{
+		MetricName: "pool",
+		Query:      "pools",
+		Fields:     map[string]string{"a": "@.a"},
+	})
+	require.Error(t, err)
+
+	_, err = New(&Config{
+		MetricName: "pool",
+		Fields:     map[string]string{"a": "@.a"},
+		Tags:       map[string]string{"b": "b"},
+	})
+	require.Error(t, err)
+}
+
+func TestParseInvalidJSON(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "pool",
+		Fields:     map[string]string{"a": "@.a"},
+	})
+	require.NoError(t, err)
+
+	_, err = parser.Parse([]byte(`{"a": `))
+	require.Error(t, err)
+}
@@ -0,0 +1,165 @@
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	stepChild stepKind = iota
+	stepIndex
+	stepWildcard
+)
+
+type step struct {
+	kind  stepKind
+	name  string
+	index int
+}
+
+// Path is a compiled JSONPath expression.  The supported subset is the root
+// node `$`, the current node `@`, dot and bracket child access, array
+// indexes, including negative ones, and the `*` wildcard.
+type Path struct {
+	expr     string
+	relative bool
+	steps    []step
+}
+
+// Compile parses a JSONPath expression.
+func Compile(expr string) (*Path, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	p := &Path{expr: expr}
+	switch expr[0] {
+	case '$':
+	case '@':
+		p.relative = true
+	default:
+		return nil, fmt.Errorf("path %q must start with '$' or '@'", expr)
+	}
+
+	rest := expr[1:]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			switch name {
+			case "":
+				return nil, fmt.Errorf("path %q: empty name after '.'", expr)
+			case "*":
+				p.steps = append(p.steps, step{kind: stepWildcard})
+			default:
+				p.steps = append(p.steps, step{kind: stepChild, name: name})
+			}
+		case '[':
+			s, n, err := parseBracket(rest)
+			if err != nil {
+				return nil, fmt.Errorf("path %q: %v", expr, err)
+			}
+			p.steps = append(p.steps, s)
+			rest = rest[n:]
+		default:
+			return nil, fmt.Errorf("path %q: unexpected character %q", expr, rest[0])
+		}
+	}
+	return p, nil
+}
+
+// parseBracket parses a bracketed step at the start of s and returns the
+// step along with the number of bytes consumed.
+func parseBracket(s string) (step, int, error) {
+	if len(s) > 1 && (s[1] == '\'' || s[1] == '"') {
+		quote := s[1]
+		end := strings.IndexByte(s[2:], quote)
+		if end == -1 || len(s) < end+4 || s[end+3] != ']' {
+			return step{}, 0, fmt.Errorf("unterminated quoted name")
+		}
+		return step{kind: stepChild, name: s[2 : end+2]}, end + 4, nil
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end == -1 {
+		return step{}, 0, fmt.Errorf("missing ']'")
+	}
+	content := strings.TrimSpace(s[1:end])
+	if content == "*" {
+		return step{kind: stepWildcard}, end + 1, nil
+	}
+	index, err := strconv.Atoi(content)
+	if err != nil {
+		return step{}, 0, fmt.Errorf("invalid array index %q", content)
+	}
+	return step{kind: stepIndex, index: index}, end + 1, nil
+}
+
+// Select returns all values matched by the path.  Relative paths are
+// evaluated against current, absolute paths against root.
+func (p *Path) Select(root, current interface{}) []interface{} {
+	nodes := []interface{}{root}
+	if p.relative {
+		nodes = []interface{}{current}
+	}
+
+	for _, s := range p.steps {
+		next := make([]interface{}, 0, len(nodes))
+		for _, node := range nodes {
+			next = s.apply(node, next)
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+func (s step) apply(node interface{}, out []interface{}) []interface{} {
+	switch s.kind {
+	case stepChild:
+		if obj, ok := node.(map[string]interface{}); ok {
+			if v, ok := obj[s.name]; ok {
+				out = append(out, v)
+			}
+		}
+	case stepIndex:
+		if ary, ok := node.([]interface{}); ok {
+			i := s.index
+			if i < 0 {
+				i += len(ary)
+			}
+			if i >= 0 && i < len(ary) {
+				out = append(out, ary[i])
+			}
+		}
+	case stepWildcard:
+		switch v := node.(type) {
+		case []interface{}:
+			out = append(out, v...)
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				out = append(out, v[k])
+			}
+		}
+	}
+	return out
+}
+
+func (p *Path) String() string {
+	return p.expr
+}
@@ -13,10 +13,14 @@ import (
 	"github.com/influxdata/telegraf/plugins/parsers/grok"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
+	"github.com/influxdata/telegraf/plugins/parsers/jsonpath"
 	"github.com/influxdata/telegraf/plugins/parsers/logfmt"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
+	"github.com/influxdata/telegraf/plugins/parsers/table"
 	"github.com/influxdata/telegraf/plugins/parsers/value"
 	"github.com/influxdata/telegraf/plugins/parsers/wavefront"
+	"github.com/influxdata/telegraf/plugins/parsers/xml"
+	"github.com/influxdata/telegraf/selfstat"
 )
 
 type ParserFunc func() (Parser, error)
@@ -68,6 +72,10 @@ type Config struct {
 	// Templates only apply to Graphite data.
 	Templates []string `toml:"templates"`
 
+	// How lines that fail to parse are handled by the influx parser, one of
+	// error (default), drop_line or drop_batch
+	InfluxOnParseError string `toml:"influx_on_parse_error"`
+
 	// TagKeys only apply to JSON data
 	TagKeys []string `toml:"tag_keys"`
 	// Array of glob pattern strings keys that should be added as string fields.
@@ -92,6 +100,14 @@ type Config struct {
 	// Whether to continue if a JSON object can't be coerced
 	JSONStrict bool `toml:"json_strict"`
 
+	// Whether the data holds a stream of concatenated JSON documents
+	JSONStream bool `toml:"json_stream"`
+
+	// JSONPath expressions for the jsonpath parser
+	JSONPathQuery  string            `toml:"jsonpath_query"`
+	JSONPathFields map[string]string `toml:"jsonpath_fields"`
+	JSONPathTags   map[string]string `toml:"jsonpath_tags"`
+
 	// Authentication file for collectd
 	CollectdAuthFile string `toml:"collectd_auth_file"`
 	// One of none (default), sign, or encrypt
@@ -148,6 +164,16 @@ type Config struct {
 
 	// FormData configuration
 	FormUrlencodedTagKeys []string `toml:"form_urlencoded_tag_keys"`
+
+	// table configuration
+	TableColumnNames []string `toml:"table_column_names"`
+	TableSkipRows    int      `toml:"table_skip_rows"`
+	TableTagColumns  []string `toml:"table_tag_columns"`
+
+	// XPath expressions for the xml parser
+	XMLQuery  string            `toml:"xml_query"`
+	XMLFields map[string]string `toml:"xml_fields"`
+	XMLTags   map[string]string `toml:"xml_tags"`
 }
 
 // NewParser returns a Parser interface based on the given config.
@@ -168,13 +194,29 @@ func NewParser(config *Config) (Parser, error) {
 				Timezone:     config.JSONTimezone,
 				DefaultTags:  config.DefaultTags,
 				Strict:       config.JSONStrict,
+				Stream:       config.JSONStream,
+			},
+		)
+	case "jsonpath":
+		parser, err = jsonpath.New(
+			&jsonpath.Config{
+				MetricName:  config.MetricName,
+				Query:       config.JSONPathQuery,
+				Fields:      config.JSONPathFields,
+				Tags:        config.JSONPathTags,
+				DefaultTags: config.DefaultTags,
 			},
 		)
 	case "value":
 		parser, err = NewValueParser(config.MetricName,
 			config.DataType, config.DefaultTags)
 	case "influx":
-		parser, err = NewInfluxParser()
+		if config.InfluxOnParseError == "" {
+			parser, err = NewInfluxParser()
+		} else {
+			parser, err = newInfluxParserWithErrorHandling(
+				config.MetricName, config.InfluxOnParseError)
+		}
 	case "nagios":
 		parser, err = NewNagiosParser()
 	case "graphite":
@@ -227,6 +269,24 @@ func NewParser(config *Config) (Parser, error) {
 			config.DefaultTags,
 			config.FormUrlencodedTagKeys,
 		)
+	case "table":
+		parser, err = NewTableParser(
+			config.MetricName,
+			config.TableSkipRows,
+			config.TableColumnNames,
+			config.TableTagColumns,
+			config.DefaultTags,
+		)
+	case "xml":
+		parser, err = xml.New(
+			&xml.Config{
+				MetricName:  config.MetricName,
+				Query:       config.XMLQuery,
+				Fields:      config.XMLFields,
+				Tags:        config.XMLTags,
+				DefaultTags: config.DefaultTags,
+			},
+		)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -318,6 +378,25 @@ func NewInfluxParser() (Parser, error) {
 	return influx.NewParser(handler), nil
 }
 
+func newInfluxParserWithErrorHandling(metricName string, onParseError string) (Parser, error) {
+	switch onParseError {
+	case influx.OnParseErrorError, influx.OnParseErrorDropLine, influx.OnParseErrorDropBatch:
+	default:
+		return nil, fmt.Errorf("invalid influx_on_parse_error: %s", onParseError)
+	}
+
+	tags := map[string]string{"data_format": "influx"}
+	if metricName != "" {
+		tags["input"] = metricName
+	}
+
+	handler := influx.NewMetricHandler()
+	parser := influx.NewParser(handler)
+	parser.OnParseError = onParseError
+	parser.MalformedLines = selfstat.Register("parser", "malformed_lines", tags)
+	return parser, nil
+}
+
 func NewGraphiteParser(
 	separator string,
 	templates []string,
@@ -392,3 +471,25 @@ func NewFormUrlencodedParser(
 		TagKeys:     tagKeys,
 	}, nil
 }
+
+// NewTableParser returns a parser for whitespace aligned tabular text.
+func NewTableParser(
+	metricName string,
+	skipRows int,
+	columnNames []string,
+	tagColumns []string,
+	defaultTags map[string]string,
+) (Parser, error) {
+	if skipRows < 0 {
+		return nil, fmt.Errorf("table_skip_rows must not be negative, got: %d", skipRows)
+	}
+
+	return &table.Parser{
+		MetricName:  metricName,
+		SkipRows:    skipRows,
+		ColumnNames: columnNames,
+		TagColumns:  tagColumns,
+		DefaultTags: defaultTags,
+		TimeFunc:    time.Now,
+	}, nil
+}
@@ -0,0 +1,157 @@
+package table
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+var (
+	ErrNoMetric = fmt.Errorf("no metric in line")
+)
+
+// Parser decodes whitespace aligned tabular text, as printed by tools such as
+// vmstat, df or nstat, into metrics with one metric per row.
+type Parser struct {
+	MetricName  string
+	SkipRows    int
+	ColumnNames []string
+	TagColumns  []string
+	DefaultTags map[string]string
+	TimeFunc    func() time.Time
+}
+
+// Parse converts the table in buf to metrics.  Unless ColumnNames is set the
+// first line after the skipped rows is used as the header.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+
+	for i := 0; i < p.SkipRows; i++ {
+		if !scanner.Scan() {
+			return nil, scanner.Err()
+		}
+	}
+
+	columns := p.ColumnNames
+	if len(columns) == 0 {
+		for scanner.Scan() {
+			columns = strings.Fields(scanner.Text())
+			if len(columns) != 0 {
+				break
+			}
+		}
+		if len(columns) == 0 {
+			return nil, scanner.Err()
+		}
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	for scanner.Scan() {
+		m, err := p.parseRow(columns, scanner.Text())
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			continue
+		}
+		metrics = append(metrics, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// ParseLine parses a single data row, ColumnNames must be set.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	if len(p.ColumnNames) == 0 {
+		return nil, fmt.Errorf("table_column_names must be set to parse a single line")
+	}
+
+	m, err := p.parseRow(p.ColumnNames, line)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrNoMetric
+	}
+	return m, nil
+}
+
+// SetDefaultTags adds tags to the metrics outputs of Parse and ParseLine.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) parseRow(columns []string, line string) (telegraf.Metric, error) {
+	cells := strings.Fields(line)
+	if len(cells) == 0 {
+		return nil, nil
+	}
+
+	// Free text in the last column, such as a command line, may contain
+	// spaces; keep it together instead of dropping the remainder.
+	if len(cells) > len(columns) {
+		last := strings.Join(cells[len(columns)-1:], " ")
+		cells = append(cells[:len(columns)-1], last)
+	}
+
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	fields := make(map[string]interface{})
+
+	for i, cell := range cells {
+		name := columns[i]
+		if p.isTagColumn(name) {
+			tags[name] = cell
+			continue
+		}
+
+		// Non-numeric cells are labels describing the row.
+		if v, ok := parseNumber(cell); ok {
+			fields[name] = v
+		} else {
+			tags[name] = cell
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	return metric.New(p.MetricName, tags, fields, p.TimeFunc())
+}
+
+func (p *Parser) isTagColumn(name string) bool {
+	for _, tag := range p.TagColumns {
+		if tag == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNumber detects integer and float cells; a trailing percent sign, as
+// used by df, is ignored.
+func parseNumber(s string) (interface{}, bool) {
+	s = strings.TrimSuffix(s, "%")
+	if iValue, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return iValue, true
+	}
+	if fValue, err := strconv.ParseFloat(s, 64); err == nil {
+		if math.IsNaN(fValue) || math.IsInf(fValue, 0) {
+			return nil, false
+		}
+		return fValue, true
+	}
+	return nil, false
+}
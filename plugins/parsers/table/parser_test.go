@@ -0,0 +1,219 @@
+package table
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var DefaultTime = func() time.Time {
+	return time.Unix(3600, 0)
+}
+
+func TestParseHeader(t *testing.T) {
+	p := Parser{
+		MetricName: "vmstat",
+		SkipRows:   1,
+		TimeFunc:   DefaultTime,
+	}
+	vmstat := `procs -----------memory---------- ---swap--
+ r  b   swpd   free   buff
+ 2  0      0 812344 120412
+ 1  0      0 812100 120412
+`
+
+	metrics, err := p.Parse([]byte(vmstat))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"vmstat",
+			map[string]string{},
+			map[string]interface{}{
+				"r":    int64(2),
+				"b":    int64(0),
+				"swpd": int64(0),
+				"free": int64(812344),
+				"buff": int64(120412),
+			},
+			time.Unix(3600, 0),
+		),
+		testutil.MustMetric(
+			"vmstat",
+			map[string]string{},
+			map[string]interface{}{
+				"r":    int64(1),
+				"b":    int64(0),
+				"swpd": int64(0),
+				"free": int64(812100),
+				"buff": int64(120412),
+			},
+			time.Unix(3600, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseColumnNames(t *testing.T) {
+	p := Parser{
+		MetricName:  "df",
+		SkipRows:    1,
+		ColumnNames: []string{"filesystem", "blocks", "used", "available", "used_percent", "mounted_on"},
+		TimeFunc:    DefaultTime,
+	}
+	df := `Filesystem     1K-blocks    Used Available Use% Mounted on
+/dev/sda1       41152832 9009180  30030404  24% /
+tmpfs            1015268       0   1015268   0% /dev/shm
+`
+
+	metrics, err := p.Parse([]byte(df))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"df",
+			map[string]string{
+				"filesystem": "/dev/sda1",
+				"mounted_on": "/",
+			},
+			map[string]interface{}{
+				"blocks":       int64(41152832),
+				"used":         int64(9009180),
+				"available":    int64(30030404),
+				"used_percent": int64(24),
+			},
+			time.Unix(3600, 0),
+		),
+		testutil.MustMetric(
+			"df",
+			map[string]string{
+				"filesystem": "tmpfs",
+				"mounted_on": "/dev/shm",
+			},
+			map[string]interface{}{
+				"blocks":       int64(1015268),
+				"used":         int64(0),
+				"available":    int64(1015268),
+				"used_percent": int64(0),
+			},
+			time.Unix(3600, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseTagColumns(t *testing.T) {
+	p := Parser{
+		MetricName:  "nstat",
+		SkipRows:    1,
+		ColumnNames: []string{"name", "value", "rate"},
+		TagColumns:  []string{"name"},
+		DefaultTags: map[string]string{"host": "localhost"},
+		TimeFunc:    DefaultTime,
+	}
+	nstat := `#kernel
+IpInReceives                    3745               0.0
+IpForwDatagrams                 12                 0.5
+`
+
+	metrics, err := p.Parse([]byte(nstat))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"nstat",
+			map[string]string{
+				"host": "localhost",
+				"name": "IpInReceives",
+			},
+			map[string]interface{}{
+				"value": int64(3745),
+				"rate":  float64(0),
+			},
+			time.Unix(3600, 0),
+		),
+		testutil.MustMetric(
+			"nstat",
+			map[string]string{
+				"host": "localhost",
+				"name": "IpForwDatagrams",
+			},
+			map[string]interface{}{
+				"value": int64(12),
+				"rate":  float64(0.5),
+			},
+			time.Unix(3600, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseTrailingText(t *testing.T) {
+	p := Parser{
+		MetricName: "ps",
+		TagColumns: []string{"PID"},
+		TimeFunc:   DefaultTime,
+	}
+	ps := `  PID %CPU COMMAND
+  812  1.5 /usr/bin/telegraf --config /etc/telegraf/telegraf.conf
+
+`
+
+	metrics, err := p.Parse([]byte(ps))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"ps",
+			map[string]string{
+				"PID":     "812",
+				"COMMAND": "/usr/bin/telegraf --config /etc/telegraf/telegraf.conf",
+			},
+			map[string]interface{}{
+				"%CPU": float64(1.5),
+			},
+			time.Unix(3600, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseEmpty(t *testing.T) {
+	p := Parser{
+		MetricName: "empty",
+		TimeFunc:   DefaultTime,
+	}
+
+	metrics, err := p.Parse([]byte(""))
+	require.NoError(t, err)
+	require.Len(t, metrics, 0)
+}
+
+func TestParseLine(t *testing.T) {
+	p := Parser{
+		MetricName:  "table",
+		ColumnNames: []string{"a", "b"},
+		TimeFunc:    DefaultTime,
+	}
+
+	m, err := p.ParseLine("42 1.5")
+	require.NoError(t, err)
+
+	expected := testutil.MustMetric(
+		"table",
+		map[string]string{},
+		map[string]interface{}{
+			"a": int64(42),
+			"b": float64(1.5),
+		},
+		time.Unix(3600, 0),
+	)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{expected}, []telegraf.Metric{m})
+
+	p.ColumnNames = nil
+	_, err = p.ParseLine("42 1.5")
+	require.Error(t, err)
+}
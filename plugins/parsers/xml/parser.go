@@ -0,0 +1,70 @@
+package xml
+
+import (
+	"strconv"
+
+	"github.com/influxdata/telegraf/plugins/common/pathparser"
+)
+
+var (
+	ErrNoMetric = pathparser.ErrNoMetric
+)
+
+type Config = pathparser.Config
+
+// Parser extracts fields and tags from XML documents using XPath
+// expressions.
+type Parser = pathparser.Parser
+
+var format = &pathparser.Format{
+	Name:         "xml",
+	DefaultQuery: "/*",
+	Compile: func(expr string) (pathparser.Path, error) {
+		path, err := Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		return nodePath{path}, nil
+	},
+	Parse: func(buf []byte) (interface{}, error) {
+		return parseDocument(buf)
+	},
+	Tag: func(v interface{}) (string, bool) {
+		value := v.(*node).value()
+		return value, value != ""
+	},
+	Field: func(v interface{}) (interface{}, bool) {
+		value := v.(*node).value()
+		return convert(value), value != ""
+	},
+}
+
+func New(config *Config) (*Parser, error) {
+	return pathparser.New(format, config)
+}
+
+// nodePath selects the nodes of a document for the parser.
+type nodePath struct {
+	*Path
+}
+
+func (p nodePath) Select(root, current interface{}) []interface{} {
+	nodes := p.Path.Select(root.(*node), current.(*node))
+	values := make([]interface{}, len(nodes))
+	for i, n := range nodes {
+		values[i] = n
+	}
+	return values
+}
+
+// convert detects the type of a text value.
+func convert(value string) interface{} {
+	if iValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return iValue
+	} else if fValue, err := strconv.ParseFloat(value, 64); err == nil {
+		return fValue
+	} else if bValue, err := strconv.ParseBool(value); err == nil {
+		return bValue
+	}
+	return value
+}
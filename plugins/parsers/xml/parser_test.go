@@ -0,0 +1,227 @@
+package xml
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const domstats = `<?xml version="1.0"?>
+<domstats host="kvm01">
+  <domain name="web" state="running">
+    <cpu><time>1200</time><usage>12.5</usage></cpu>
+    <disk type="file" dev="vda"><rd_bytes>2048</rd_bytes></disk>
+    <disk type="block" dev="vdb"><rd_bytes>4096</rd_bytes></disk>
+    <autostart>true</autostart>
+  </domain>
+  <domain name="db" state="paused">
+    <cpu><time>300</time><usage>0.5</usage></cpu>
+    <disk type="file" dev="vda"><rd_bytes>512</rd_bytes></disk>
+    <autostart>false</autostart>
+  </domain>
+</domstats>
+`
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{expr: "/"},
+		{expr: "/domstats/domain"},
+		{expr: "//domain[@state='running']"},
+		{expr: "cpu/time"},
+		{expr: "cpu/time/text()"},
+		{expr: "disk[2]/@dev"},
+		{expr: "../@host"},
+		{expr: "", wantErr: true},
+		{expr: "/domstats//", wantErr: true},
+		{expr: "@name/cpu", wantErr: true},
+		{expr: "disk[0]", wantErr: true},
+		{expr: "disk[@type=file]", wantErr: true},
+		{expr: "disk[1", wantErr: true},
+		{expr: "text()/cpu", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Compile(tt.expr)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "libvirt",
+		Query:      "/domstats/domain",
+		Fields: map[string]string{
+			"cpu_time":       "cpu/time",
+			"cpu_usage":      "cpu/usage/text()",
+			"vda_read_bytes": "disk[@dev='vda']/rd_bytes",
+			"autostart":      "autostart",
+			"state":          "@state",
+		},
+		Tags: map[string]string{
+			"domain": "@name",
+			"host":   "../@host",
+		},
+	})
+	require.NoError(t, err)
+	parser.SetTimeFunc(func() time.Time { return time.Unix(42, 0) })
+
+	metrics, err := parser.Parse([]byte(domstats))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"libvirt",
+			map[string]string{
+				"domain": "web",
+				"host":   "kvm01",
+			},
+			map[string]interface{}{
+				"cpu_time":       int64(1200),
+				"cpu_usage":      float64(12.5),
+				"vda_read_bytes": int64(2048),
+				"autostart":      true,
+				"state":          "running",
+			},
+			time.Unix(42, 0),
+		),
+		testutil.MustMetric(
+			"libvirt",
+			map[string]string{
+				"domain": "db",
+				"host":   "kvm01",
+			},
+			map[string]interface{}{
+				"cpu_time":       int64(300),
+				"cpu_usage":      float64(0.5),
+				"vda_read_bytes": int64(512),
+				"autostart":      false,
+				"state":          "paused",
+			},
+			time.Unix(42, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseDescendant(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "disk",
+		Query:      "//disk[@type='block']",
+		Fields: map[string]string{
+			"read_bytes": "rd_bytes",
+		},
+		Tags: map[string]string{
+			"dev":    "@dev",
+			"domain": "../@name",
+			"host":   "/domstats/@host",
+		},
+	})
+	require.NoError(t, err)
+	parser.SetTimeFunc(func() time.Time { return time.Unix(42, 0) })
+
+	m, err := parser.ParseLine(domstats)
+	require.NoError(t, err)
+
+	expected := testutil.MustMetric(
+		"disk",
+		map[string]string{
+			"dev":    "vdb",
+			"domain": "web",
+			"host":   "kvm01",
+		},
+		map[string]interface{}{
+			"read_bytes": int64(4096),
+		},
+		time.Unix(42, 0),
+	)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{expected}, []telegraf.Metric{m})
+}
+
+func TestParseDefaultQuery(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "libvirt",
+		Fields: map[string]string{
+			"db_cpu_time": "domain[2]/cpu/time",
+			"missing":     "domain[3]/cpu/time",
+		},
+		DefaultTags: map[string]string{"source": "virsh"},
+	})
+	require.NoError(t, err)
+	parser.SetTimeFunc(func() time.Time { return time.Unix(42, 0) })
+
+	metrics, err := parser.Parse([]byte(domstats))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"libvirt",
+			map[string]string{"source": "virsh"},
+			map[string]interface{}{
+				"db_cpu_time": int64(300),
+			},
+			time.Unix(42, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseMultipleMatches(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "libvirt",
+		Query:      "/domstats/domain",
+		Fields: map[string]string{
+			"read_bytes": "disk/rd_bytes",
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = parser.Parse([]byte(domstats))
+	require.Error(t, err)
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "libvirt",
+		Fields:     map[string]string{"a": "a"},
+	})
+	require.NoError(t, err)
+
+	_, err = parser.Parse([]byte(`<domstats><domain></domstats>`))
+	require.Error(t, err)
+
+	_, err = parser.Parse([]byte(``))
+	require.Error(t, err)
+
+	_, err = parser.ParseLine(`<domstats/>`)
+	require.Equal(t, ErrNoMetric, err)
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New(&Config{MetricName: "libvirt"})
+	require.Error(t, err)
+
+	_, err = New(&Config{
+		MetricName: "libvirt",
+		Query:      "/domstats//",
+		Fields:     map[string]string{"a": "a"},
+	})
+	require.Error(t, err)
+
+	_, err = New(&Config{
+		MetricName: "libvirt",
+		Fields:     map[string]string{"a": "a"},
+		Tags:       map[string]string{"b": "b[x]"},
+	})
+	require.Error(t, err)
+}
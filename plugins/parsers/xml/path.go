@@ -0,0 +1,284 @@
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// node is an element, or for attribute selections an attribute, of a parsed
+// XML document.
+type node struct {
+	name     string
+	attrs    []xml.Attr
+	children []*node
+	parent   *node
+	text     bytes.Buffer
+}
+
+func (n *node) value() string {
+	return strings.TrimSpace(n.text.String())
+}
+
+// parseDocument builds a node tree from buf, the returned node is the
+// document node whose only child is the root element.
+func parseDocument(buf []byte) (*node, error) {
+	doc := &node{}
+	current := doc
+
+	decoder := xml.NewDecoder(bytes.NewReader(buf))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			child := &node{
+				name:   t.Name.Local,
+				attrs:  t.Attr,
+				parent: current,
+			}
+			current.children = append(current.children, child)
+			current = child
+		case xml.EndElement:
+			current = current.parent
+		case xml.CharData:
+			current.text.Write(t)
+		}
+	}
+
+	if len(doc.children) == 0 {
+		return nil, fmt.Errorf("no root element")
+	}
+	return doc, nil
+}
+
+type axis int
+
+const (
+	axisChild axis = iota
+	axisDescendant
+)
+
+type pathStep struct {
+	axis      axis
+	name      string
+	attribute bool
+	position  int
+	attrName  string
+	attrValue string
+}
+
+// Path is a compiled XPath expression.  The supported subset is absolute and
+// relative location paths made of element names, `*`, `.`, `..`, the `//`
+// descendant shorthand, a trailing `@attribute` or `text()` step and the
+// predicates `[n]` and `[@name='value']`.
+type Path struct {
+	expr     string
+	absolute bool
+	steps    []pathStep
+}
+
+// Compile parses an XPath expression.
+func Compile(expr string) (*Path, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	p := &Path{expr: expr}
+	rest := expr
+	if strings.HasPrefix(rest, "/") {
+		p.absolute = true
+	} else {
+		rest = "/" + rest
+	}
+
+	for rest != "" {
+		s := pathStep{axis: axisChild}
+		if strings.HasPrefix(rest, "//") {
+			s.axis = axisDescendant
+			rest = rest[2:]
+		} else if strings.HasPrefix(rest, "/") {
+			rest = rest[1:]
+		} else {
+			return nil, fmt.Errorf("path %q: expected '/'", expr)
+		}
+
+		end := stepEnd(rest)
+		token := rest[:end]
+		rest = rest[end:]
+
+		if i := strings.IndexByte(token, '['); i != -1 {
+			if err := s.parsePredicate(token[i:]); err != nil {
+				return nil, fmt.Errorf("path %q: %v", expr, err)
+			}
+			token = token[:i]
+		}
+
+		switch {
+		case token == "":
+			if p.absolute && len(p.steps) == 0 && rest == "" && s.axis == axisChild {
+				return p, nil
+			}
+			return nil, fmt.Errorf("path %q: empty step", expr)
+		case token == "text()":
+			if rest != "" {
+				return nil, fmt.Errorf("path %q: text() must be the last step", expr)
+			}
+			return p, nil
+		case strings.HasPrefix(token, "@"):
+			if rest != "" {
+				return nil, fmt.Errorf("path %q: attributes must be the last step", expr)
+			}
+			s.attribute = true
+			s.name = token[1:]
+			if s.name == "" {
+				return nil, fmt.Errorf("path %q: empty attribute name", expr)
+			}
+		default:
+			s.name = token
+		}
+		p.steps = append(p.steps, s)
+	}
+	return p, nil
+}
+
+// stepEnd returns the index of the next step separator, ignoring slashes
+// inside of predicates.
+func stepEnd(s string) int {
+	depth := 0
+	for i, c := range s {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '/':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+func (s *pathStep) parsePredicate(pred string) error {
+	if !strings.HasSuffix(pred, "]") {
+		return fmt.Errorf("missing ']'")
+	}
+	content := strings.TrimSpace(pred[1 : len(pred)-1])
+
+	if strings.HasPrefix(content, "@") {
+		parts := strings.SplitN(content[1:], "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid predicate %q", pred)
+		}
+		value := strings.TrimSpace(parts[1])
+		if len(value) < 2 || (value[0] != '\'' && value[0] != '"') || value[len(value)-1] != value[0] {
+			return fmt.Errorf("predicate value must be quoted in %q", pred)
+		}
+		s.attrName = strings.TrimSpace(parts[0])
+		s.attrValue = value[1 : len(value)-1]
+		return nil
+	}
+
+	position, err := strconv.Atoi(content)
+	if err != nil || position < 1 {
+		return fmt.Errorf("invalid position %q", content)
+	}
+	s.position = position
+	return nil
+}
+
+// Select returns the nodes matched by the path.  Absolute paths start at the
+// document node, relative paths at current.
+func (p *Path) Select(doc, current *node) []*node {
+	nodes := []*node{current}
+	if p.absolute {
+		nodes = []*node{doc}
+	}
+
+	for _, s := range p.steps {
+		next := make([]*node, 0, len(nodes))
+		for _, n := range nodes {
+			next = append(next, s.apply(n)...)
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+func (s pathStep) apply(n *node) []*node {
+	candidates := []*node{n}
+	if s.axis == axisDescendant {
+		candidates = descendantsOrSelf(n, candidates[:0])
+	}
+
+	var matches []*node
+	for _, c := range candidates {
+		switch {
+		case s.attribute:
+			for _, attr := range c.attrs {
+				if attr.Name.Local == s.name {
+					a := &node{name: attr.Name.Local, parent: c}
+					a.text.WriteString(attr.Value)
+					matches = append(matches, a)
+				}
+			}
+		case s.name == ".":
+			matches = append(matches, c)
+		case s.name == "..":
+			if c.parent != nil {
+				matches = append(matches, c.parent)
+			}
+		default:
+			var children []*node
+			for _, child := range c.children {
+				if (s.name == "*" || child.name == s.name) && s.matchAttr(child) {
+					children = append(children, child)
+				}
+			}
+			if s.position > 0 {
+				if s.position > len(children) {
+					continue
+				}
+				children = children[s.position-1 : s.position]
+			}
+			matches = append(matches, children...)
+		}
+	}
+	return matches
+}
+
+func (s pathStep) matchAttr(n *node) bool {
+	if s.attrName == "" {
+		return true
+	}
+	for _, attr := range n.attrs {
+		if attr.Name.Local == s.attrName && attr.Value == s.attrValue {
+			return true
+		}
+	}
+	return false
+}
+
+func descendantsOrSelf(n *node, out []*node) []*node {
+	out = append(out, n)
+	for _, child := range n.children {
+		out = descendantsOrSelf(child, out)
+	}
+	return out
+}
+
+func (p *Path) String() string {
+	return p.expr
+}
@@ -0,0 +1,195 @@
+package cardinality
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Maximum number of metrics of each measurement passed per period, the
+  ## metrics past the limit are dropped.  0 disables the limit.
+  # max_metrics = 0
+
+  ## Period over which max_metrics is counted.
+  # period = "10s"
+
+  ## Maximum number of unique values of each tag per measurement, metrics
+  ## adding a new value past the limit are dropped.  0 disables the limit.
+  # max_unique_tag_values = 0
+
+  ## Tags to apply max_unique_tag_values to, by default all tags.
+  # tags = ["*"]
+`
+
+// measurement of the metrics emitted when a limit is reached
+const warningMeasurement = "cardinality_limit"
+
+type Cardinality struct {
+	MaxMetrics         int               `toml:"max_metrics"`
+	Period             internal.Duration `toml:"period"`
+	MaxUniqueTagValues int               `toml:"max_unique_tag_values"`
+	Tags               []string          `toml:"tags"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	tagFilter   filter.Filter
+	periodStart time.Time
+	// number of metrics passed per measurement in the period
+	counts map[string]int
+	// values seen per measurement and tag key
+	values map[tagKey]map[string]bool
+	// limits already warned about in the period
+	warned map[warning]bool
+
+	now func() time.Time
+}
+
+type tagKey struct {
+	name string
+	key  string
+}
+
+type warning struct {
+	name  string
+	limit string
+	tag   string
+}
+
+func (c *Cardinality) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *Cardinality) Description() string {
+	return "Drop metrics exceeding a limit on the number of metrics or unique tag values."
+}
+
+func (c *Cardinality) Init() error {
+	if c.MaxMetrics < 0 {
+		return fmt.Errorf("max_metrics must not be negative")
+	}
+	if c.MaxUniqueTagValues < 0 {
+		return fmt.Errorf("max_unique_tag_values must not be negative")
+	}
+	if c.MaxMetrics > 0 && c.Period.Duration <= 0 {
+		return fmt.Errorf("period must be positive")
+	}
+
+	var err error
+	c.tagFilter, err = filter.Compile(c.Tags)
+	if err != nil {
+		return err
+	}
+
+	c.counts = make(map[string]int)
+	c.values = make(map[tagKey]map[string]bool)
+	c.warned = make(map[warning]bool)
+	return nil
+}
+
+func (c *Cardinality) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	now := c.now()
+	if now.Sub(c.periodStart) >= c.Period.Duration {
+		c.periodStart = now
+		c.counts = make(map[string]int)
+		c.warned = make(map[warning]bool)
+	}
+
+	out := make([]telegraf.Metric, 0, len(in))
+	var warnings []telegraf.Metric
+	for _, m := range in {
+		w, ok := c.accept(m)
+		if ok {
+			out = append(out, m)
+			continue
+		}
+
+		m.Drop()
+		if !c.warned[w] {
+			c.warned[w] = true
+			warnings = append(warnings, c.warn(w, m, now))
+		}
+	}
+	return append(out, warnings...)
+}
+
+// accept returns true if the metric is within the limits, otherwise the
+// limit that was exceeded.
+func (c *Cardinality) accept(m telegraf.Metric) (warning, bool) {
+	if c.MaxMetrics > 0 && c.counts[m.Name()] >= c.MaxMetrics {
+		return warning{name: m.Name(), limit: "metrics"}, false
+	}
+
+	if c.MaxUniqueTagValues > 0 {
+		// Check all tags first, so that a dropped metric does not add values.
+		for _, tag := range m.TagList() {
+			if !c.matchTag(tag.Key) {
+				continue
+			}
+			values := c.values[tagKey{m.Name(), tag.Key}]
+			if !values[tag.Value] && len(values) >= c.MaxUniqueTagValues {
+				return warning{name: m.Name(), limit: "tag_values", tag: tag.Key}, false
+			}
+		}
+
+		for _, tag := range m.TagList() {
+			if !c.matchTag(tag.Key) {
+				continue
+			}
+			key := tagKey{m.Name(), tag.Key}
+			if c.values[key] == nil {
+				c.values[key] = make(map[string]bool)
+			}
+			c.values[key][tag.Value] = true
+		}
+	}
+
+	c.counts[m.Name()]++
+	return warning{}, true
+}
+
+func (c *Cardinality) matchTag(key string) bool {
+	return c.tagFilter == nil || c.tagFilter.Match(key)
+}
+
+// warn logs that a limit was reached and returns the metric reporting it, in
+// the route of the dropped metric.
+func (c *Cardinality) warn(w warning, dropped telegraf.Metric, now time.Time) telegraf.Metric {
+	tags := map[string]string{
+		"measurement": w.name,
+		"limit":       w.limit,
+	}
+	var limit int
+	switch w.limit {
+	case "metrics":
+		limit = c.MaxMetrics
+		c.Log.Warnf("More than %d %q metrics within %s, dropping the rest",
+			limit, w.name, c.Period.Duration)
+	case "tag_values":
+		limit = c.MaxUniqueTagValues
+		tags["tag"] = w.tag
+		c.Log.Warnf("More than %d values of tag %q in %q metrics, dropping metrics with new values",
+			limit, w.tag, w.name)
+	}
+
+	m, _ := metric.New(warningMeasurement, tags,
+		map[string]interface{}{
+			"limit": int64(limit),
+		}, now)
+	m.SetRoute(dropped.Route())
+	return m
+}
+
+func init() {
+	processors.Add("cardinality", func() telegraf.Processor {
+		return &Cardinality{
+			Period: internal.Duration{Duration: 10 * time.Second},
+			now:    time.Now,
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package cardinality
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newCardinality() *Cardinality {
+	return &Cardinality{
+		Period: internal.Duration{Duration: 10 * time.Second},
+		Log:    testutil.Logger{},
+		now: func() time.Time {
+			return time.Unix(0, 0)
+		},
+	}
+}
+
+func cpu(host string, value int64) telegraf.Metric {
+	return testutil.MustMetric("cpu",
+		map[string]string{
+			"host": host,
+		},
+		map[string]interface{}{
+			"value": value,
+		},
+		time.Unix(0, 0),
+	)
+}
+
+func TestMaxMetrics(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := newCardinality()
+	c.MaxMetrics = 2
+	c.now = func() time.Time { return now }
+	require.NoError(t, c.Init())
+
+	actual := c.Apply(cpu("a", 1), cpu("a", 2), cpu("a", 3))
+	actual = append(actual, c.Apply(cpu("a", 4))...)
+	expected := []telegraf.Metric{
+		cpu("a", 1),
+		cpu("a", 2),
+		testutil.MustMetric("cardinality_limit",
+			map[string]string{
+				"measurement": "cpu",
+				"limit":       "metrics",
+			},
+			map[string]interface{}{
+				"limit": int64(2),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	// The count starts over in the next period.
+	now = now.Add(10 * time.Second)
+	actual = c.Apply(cpu("a", 5))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{cpu("a", 5)}, actual)
+}
+
+func TestMaxUniqueTagValues(t *testing.T) {
+	c := newCardinality()
+	c.MaxUniqueTagValues = 2
+	require.NoError(t, c.Init())
+
+	actual := c.Apply(cpu("a", 1), cpu("b", 2), cpu("c", 3), cpu("a", 4), cpu("d", 5))
+	expected := []telegraf.Metric{
+		cpu("a", 1),
+		cpu("b", 2),
+		cpu("a", 4),
+		testutil.MustMetric("cardinality_limit",
+			map[string]string{
+				"measurement": "cpu",
+				"limit":       "tag_values",
+				"tag":         "host",
+			},
+			map[string]interface{}{
+				"limit": int64(2),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestMaxUniqueTagValuesDroppedDoesNotCount(t *testing.T) {
+	c := newCardinality()
+	c.MaxUniqueTagValues = 1
+	require.NoError(t, c.Init())
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{
+			"host": "a",
+			"cpu":  "cpu0",
+		},
+		map[string]interface{}{
+			"value": int64(1),
+		},
+		time.Unix(0, 0),
+	)
+	c.Apply(m)
+
+	// The new host value is dropped, so the cpu value is not added.
+	c.Apply(testutil.MustMetric("cpu",
+		map[string]string{
+			"host": "b",
+			"cpu":  "cpu1",
+		},
+		map[string]interface{}{
+			"value": int64(1),
+		},
+		time.Unix(0, 0),
+	))
+	require.Len(t, c.values[tagKey{"cpu", "cpu"}], 1)
+}
+
+func TestTagsFilter(t *testing.T) {
+	c := newCardinality()
+	c.MaxUniqueTagValues = 1
+	c.Tags = []string{"cpu"}
+	require.NoError(t, c.Init())
+
+	actual := c.Apply(cpu("a", 1), cpu("b", 2))
+	expected := []telegraf.Metric{
+		cpu("a", 1),
+		cpu("b", 2),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestInitErrors(t *testing.T) {
+	c := newCardinality()
+	c.MaxMetrics = -1
+	require.Error(t, c.Init())
+
+	c = newCardinality()
+	c.MaxUniqueTagValues = -1
+	require.Error(t, c.Init())
+
+	c = newCardinality()
+	c.MaxMetrics = 1
+	c.Period.Duration = 0
+	require.Error(t, c.Init())
+}
+
+func TestWarningKeepsRoute(t *testing.T) {
+	c := newCardinality()
+	c.MaxMetrics = 1
+	require.NoError(t, c.Init())
+
+	dropped := cpu("a", 2)
+	dropped.SetRoute("scripts")
+	actual := c.Apply(cpu("a", 1), dropped)
+	require.Len(t, actual, 2)
+	require.Equal(t, "cardinality_limit", actual[1].Name())
+	require.Equal(t, "scripts", actual[1].Route())
+}
@@ -27,12 +27,6 @@ func (d *Dedup) Description() string {
 	return "Filter metrics with repeating field values"
 }
 
-// Remove single item from slice
-func remove(slice []telegraf.Metric, i int) []telegraf.Metric {
-	slice[len(slice)-1], slice[i] = slice[i], slice[len(slice)-1]
-	return slice[:len(slice)-1]
-}
-
 // Remove expired items from cache
 func (d *Dedup) cleanup() {
 	// No need to cleanup cache too often. Lets save some CPU
@@ -55,43 +49,39 @@ func (d *Dedup) save(metric telegraf.Metric, id uint64) {
 	d.Cache[id].Accept()
 }
 
+// changed returns true if the fields of the metric differ from the cached
+// ones, including fields added or removed.
+func changed(metric, cached telegraf.Metric) bool {
+	if len(metric.FieldList()) != len(cached.FieldList()) {
+		return true
+	}
+	for _, f := range metric.FieldList() {
+		if value, ok := cached.GetField(f.Key); !ok || value != f.Value {
+			return true
+		}
+	}
+	return false
+}
+
 // main processing method
 func (d *Dedup) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
-	for idx, metric := range metrics {
+	passed := metrics[:0]
+	for _, metric := range metrics {
 		id := metric.HashID()
 		m, ok := d.Cache[id]
 
-		// If not in cache then just save it
-		if !ok {
-			d.save(metric, id)
-			continue
-		}
-
-		// If cache item has expired then refresh it
-		if time.Since(m.Time()) >= d.DedupInterval.Duration {
-			d.save(metric, id)
-			continue
-		}
-
-		// For each field compare value with the cached one
-		changed := false
-		for _, f := range metric.FieldList() {
-			if value, ok := m.GetField(f.Key); ok && value != f.Value {
-				changed = true
-				continue
-			}
-		}
-		// If any field value has changed then refresh the cache
-		if changed {
+		// If not in cache, expired or changed then refresh the cache
+		if !ok || time.Since(m.Time()) >= d.DedupInterval.Duration || changed(metric, m) {
 			d.save(metric, id)
+			passed = append(passed, metric)
 			continue
 		}
 
 		// In any other case remove metric from the output
-		metrics = remove(metrics, idx)
+		metric.Drop()
 	}
 	d.cleanup()
-	return metrics
+	return passed
 }
 
 func init() {
@@ -152,3 +152,51 @@ func TestCacheShrink(t *testing.T) {
 
 	require.Equal(t, 0, len(deduplicate.Cache))
 }
+
+func TestSuppressRepeatedValueInBatch(t *testing.T) {
+	deduplicate := createDedup(time.Now())
+	now := time.Now()
+	target := deduplicate.Apply(
+		createMetric("m1", 1, now.Add(-3*time.Second)),
+		createMetric("m1", 1, now.Add(-2*time.Second)),
+		createMetric("m1", 2, now.Add(-1*time.Second)),
+		createMetric("m1", 2, now),
+	)
+
+	require.Equal(t, 2, len(target))
+	value, _ := target[0].GetField("value")
+	require.Equal(t, int64(1), value)
+	value, _ = target[1].GetField("value")
+	require.Equal(t, int64(2), value)
+}
+
+func TestPassAddedField(t *testing.T) {
+	deduplicate := createDedup(time.Now())
+	source := createMetric("m1", 1, time.Now().Add(-1*time.Second))
+	deduplicate.Apply(source)
+	source = createMetric("m1", 1, time.Now())
+	source.AddField("other", int64(1))
+	target := deduplicate.Apply(source)
+
+	require.Equal(t, 1, len(target))
+}
+
+// dropMetric records whether the metric was dropped.
+type dropMetric struct {
+	telegraf.Metric
+	dropped bool
+}
+
+func (m *dropMetric) Drop() {
+	m.dropped = true
+}
+
+func TestSuppressedMetricIsDropped(t *testing.T) {
+	deduplicate := createDedup(time.Now())
+	deduplicate.Apply(createMetric("m1", 1, time.Now().Add(-1*time.Second)))
+	source := &dropMetric{Metric: createMetric("m1", 1, time.Now())}
+	target := deduplicate.Apply(source)
+
+	require.Equal(t, 0, len(target))
+	require.True(t, source.dropped)
+}
@@ -0,0 +1,403 @@
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	influxSerializer "github.com/influxdata/telegraf/plugins/serializers/influx"
+	jsonSerializer "github.com/influxdata/telegraf/plugins/serializers/json"
+)
+
+const sampleConfig = `
+  ## Command to run, with its arguments.  The command is started once and
+  ## must write one line to stdout for every line read from stdin.
+  command = ["/usr/bin/scrub-metrics", "--mode", "strict"]
+
+  ## Format of the metrics written to and read from the command, either
+  ## "influx" or "json".
+  # data_format = "influx"
+
+  ## Maximum time to wait for the command to answer each metric.  When the
+  ## command does not answer in time it is restarted.
+  # timeout = "5s"
+
+  ## Drop the metrics that could not be transformed, because the command
+  ## failed, did not answer in time or answered with an invalid metric,
+  ## instead of passing them on unchanged.
+  # drop_on_error = false
+`
+
+// Restarts of a command that keeps failing are delayed by this long.
+const restartDelay = 10 * time.Second
+
+var (
+	errTimeout = errors.New("timeout waiting for command")
+	errWaiting = errors.New("waiting to restart command")
+)
+
+type Exec struct {
+	Command     []string          `toml:"command"`
+	DataFormat  string            `toml:"data_format"`
+	Timeout     internal.Duration `toml:"timeout"`
+	DropOnError bool              `toml:"drop_on_error"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	serializer serializers.Serializer
+	parse      func(line []byte) (telegraf.Metric, error)
+
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	lines     chan []byte
+	done      chan struct{}
+	started   bool
+	lastStart time.Time
+}
+
+func (e *Exec) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Exec) Description() string {
+	return "Transform metrics by passing them through an external command."
+}
+
+func (e *Exec) Init() error {
+	if len(e.Command) == 0 {
+		return errors.New("command is required")
+	}
+	if e.Timeout.Duration <= 0 {
+		return errors.New("timeout must be greater than 0")
+	}
+
+	switch e.DataFormat {
+	case "", "influx":
+		s := influxSerializer.NewSerializer()
+		s.SetFieldTypeSupport(influxSerializer.UintSupport)
+		e.serializer = s
+		e.parse = parseInflux
+	case "json":
+		s, err := jsonSerializer.NewSerializer(time.Nanosecond)
+		if err != nil {
+			return err
+		}
+		e.serializer = s
+		e.parse = parseJSON
+	default:
+		return fmt.Errorf("unsupported data_format %q", e.DataFormat)
+	}
+	return nil
+}
+
+// start runs the command, unless it was started recently.
+func (e *Exec) start() error {
+	if e.started && time.Since(e.lastStart) < restartDelay {
+		return errWaiting
+	}
+	e.started = true
+	e.lastStart = time.Now()
+
+	cmd := exec.Command(e.Command[0], e.Command[1:]...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting command: %v", err)
+	}
+
+	lines := make(chan []byte)
+	done := make(chan struct{})
+	go e.logStderr(stderr)
+	go func() {
+		readLines(stdout, lines)
+		cmd.Wait()
+		close(done)
+	}()
+
+	e.cmd = cmd
+	e.stdin = stdin
+	e.lines = lines
+	e.done = done
+	return nil
+}
+
+func readLines(r io.Reader, lines chan<- []byte) {
+	defer close(lines)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := make([]byte, len(scanner.Bytes()))
+		copy(line, scanner.Bytes())
+		lines <- line
+	}
+}
+
+func (e *Exec) logStderr(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		e.Log.Errorf("stderr: %s", scanner.Text())
+	}
+}
+
+// stop kills the command, it is started again on the next metric.
+func (e *Exec) stop() {
+	if e.cmd == nil {
+		return
+	}
+	e.stdin.Close()
+	if e.cmd.Process != nil {
+		e.cmd.Process.Kill()
+	}
+
+	// Discard any late answers so that the reader can finish.
+	go func(lines chan []byte) {
+		for range lines {
+		}
+	}(e.lines)
+
+	e.cmd = nil
+	e.lines = nil
+	e.done = nil
+}
+
+// Close stops the command when the agent stops.  The command is asked to exit
+// by closing its stdin and killed if it does not exit within the timeout.
+func (e *Exec) Close() error {
+	if e.cmd == nil {
+		return nil
+	}
+	e.stdin.Close()
+
+	// Discard any late answers so that the reader can finish.
+	go func(lines chan []byte) {
+		for range lines {
+		}
+	}(e.lines)
+
+	timer := time.NewTimer(e.Timeout.Duration)
+	defer timer.Stop()
+	select {
+	case <-e.done:
+	case <-timer.C:
+		e.Log.Warnf("Command did not exit within %s, killing it", e.Timeout.Duration)
+		if e.cmd.Process != nil {
+			e.cmd.Process.Kill()
+		}
+	}
+
+	e.cmd = nil
+	e.lines = nil
+	e.done = nil
+	return nil
+}
+
+func (e *Exec) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := make([]telegraf.Metric, 0, len(in))
+	for i, m := range in {
+		result, err := e.transform(m)
+		if err == errWaiting {
+			return e.passOn(out, in[i:])
+		}
+		if err != nil {
+			e.Log.Errorf("Error transforming metric: %v", err)
+			if err == errTimeout || e.cmd == nil {
+				return e.passOn(out, in[i:])
+			}
+			out = e.passOn(out, in[i:i+1])
+			continue
+		}
+
+		if result == nil {
+			m.Drop()
+			continue
+		}
+		out = append(out, result)
+	}
+	return out
+}
+
+// passOn adds metrics that cannot be transformed to out unchanged, or drops
+// them with drop_on_error.
+func (e *Exec) passOn(out []telegraf.Metric, metrics []telegraf.Metric) []telegraf.Metric {
+	if !e.DropOnError {
+		return append(out, metrics...)
+	}
+	for _, m := range metrics {
+		m.Drop()
+	}
+	return out
+}
+
+// transform sends the metric to the command and applies the answer to the
+// metric.  The original metric is modified in place so that metric tracking
+// continues to work.  A nil metric is returned if the command dropped it.
+func (e *Exec) transform(m telegraf.Metric) (telegraf.Metric, error) {
+	octets, err := e.serializer.Serialize(m)
+	if err != nil {
+		return nil, err
+	}
+	octets = bytes.TrimRight(octets, "\n")
+	if bytes.IndexByte(octets, '\n') >= 0 {
+		return nil, errors.New("metric does not fit on a single line")
+	}
+
+	if e.cmd == nil {
+		if err := e.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := e.stdin.Write(append(octets, '\n')); err != nil {
+		e.stop()
+		return nil, fmt.Errorf("writing to command: %v", err)
+	}
+
+	timer := time.NewTimer(e.Timeout.Duration)
+	defer timer.Stop()
+
+	var line []byte
+	select {
+	case l, ok := <-e.lines:
+		if !ok {
+			e.stop()
+			return nil, errors.New("command exited")
+		}
+		line = bytes.TrimSpace(l)
+	case <-timer.C:
+		e.stop()
+		return nil, errTimeout
+	}
+
+	if len(line) == 0 {
+		return nil, nil
+	}
+
+	result, err := e.parse(line)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %q: %v", line, err)
+	}
+
+	m.SetName(result.Name())
+	for _, key := range tagKeys(m) {
+		m.RemoveTag(key)
+	}
+	for _, key := range fieldKeys(m) {
+		m.RemoveField(key)
+	}
+	for _, tag := range result.TagList() {
+		m.AddTag(tag.Key, tag.Value)
+	}
+	for _, field := range result.FieldList() {
+		m.AddField(field.Key, field.Value)
+	}
+	m.SetTime(result.Time())
+	return m, nil
+}
+
+func tagKeys(m telegraf.Metric) []string {
+	keys := make([]string, 0, len(m.TagList()))
+	for _, tag := range m.TagList() {
+		keys = append(keys, tag.Key)
+	}
+	return keys
+}
+
+func fieldKeys(m telegraf.Metric) []string {
+	keys := make([]string, 0, len(m.FieldList()))
+	for _, field := range m.FieldList() {
+		keys = append(keys, field.Key)
+	}
+	return keys
+}
+
+func parseInflux(line []byte) (telegraf.Metric, error) {
+	parser := influx.NewParser(influx.NewMetricHandler())
+	metrics, err := parser.Parse(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(metrics) != 1 {
+		return nil, fmt.Errorf("expected one metric, got %d", len(metrics))
+	}
+	return metrics[0], nil
+}
+
+type jsonMetric struct {
+	Name      string                 `json:"name"`
+	Tags      map[string]string      `json:"tags"`
+	Fields    map[string]interface{} `json:"fields"`
+	Timestamp *int64                 `json:"timestamp"`
+}
+
+// parseJSON reads a metric in the format of the json serializer, with the
+// timestamp in nanoseconds.  Numbers without a fraction or exponent are read
+// as integers, other numbers as floats.
+func parseJSON(line []byte) (telegraf.Metric, error) {
+	var jm jsonMetric
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	if err := decoder.Decode(&jm); err != nil {
+		return nil, err
+	}
+	if jm.Name == "" {
+		return nil, errors.New("missing name")
+	}
+
+	fields := make(map[string]interface{}, len(jm.Fields))
+	for k, v := range jm.Fields {
+		switch v := v.(type) {
+		case json.Number:
+			if i, err := v.Int64(); err == nil {
+				fields[k] = i
+				continue
+			}
+			f, err := v.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q of field %q", v, k)
+			}
+			fields[k] = f
+		case string, bool:
+			fields[k] = v
+		default:
+			return nil, fmt.Errorf("unsupported type %T of field %q", v, k)
+		}
+	}
+
+	tm := time.Now()
+	if jm.Timestamp != nil {
+		tm = time.Unix(0, *jm.Timestamp)
+	}
+	return metric.New(jm.Name, jm.Tags, fields, tm)
+}
+
+func init() {
+	processors.Add("exec", func() telegraf.Processor {
+		return &Exec{
+			DataFormat: "influx",
+			Timeout:    internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,230 @@
+// +build !windows
+
+package exec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// Answers every line with the line, or an empty line for metrics that have
+// a drop field.
+const script = `
+while IFS= read -r line; do
+  case "$line" in
+    *drop=*) echo ;;
+    *) echo "$line" | sed -e 's/^cpu/cpu_scrubbed/' -e 's/,secret=[^ ,]*//' ;;
+  esac
+done
+`
+
+func newExec(t *testing.T, format string, command ...string) *Exec {
+	e := &Exec{
+		Command:    command,
+		DataFormat: format,
+		Timeout:    internal.Duration{Duration: 5 * time.Second},
+		Log:        testutil.Logger{},
+	}
+	require.NoError(t, e.Init())
+	return e
+}
+
+func TestApplyInflux(t *testing.T) {
+	e := newExec(t, "influx", "sh", "-c", script)
+	defer e.stop()
+
+	input := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "a", "secret": "hunter2"},
+			map[string]interface{}{"usage": 42.5, "count": int64(3)},
+			time.Unix(42, 0)),
+		testutil.MustMetric("mem",
+			map[string]string{},
+			map[string]interface{}{"drop": true},
+			time.Unix(42, 0)),
+	}
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu_scrubbed",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 42.5, "count": int64(3)},
+			time.Unix(42, 0)),
+	}
+
+	for i := 0; i < 2; i++ {
+		actual := e.Apply(copyMetrics(input)...)
+		testutil.RequireMetricsEqual(t, expected, actual)
+	}
+}
+
+func TestApplyJSON(t *testing.T) {
+	e := newExec(t, "json", "sed", "-u", "s/\"usage\"/\"cpu_usage\"/")
+	defer e.stop()
+
+	actual := e.Apply(testutil.MustMetric("cpu",
+		map[string]string{"host": "a"},
+		map[string]interface{}{"usage": 42.5, "count": int64(3), "up": true},
+		time.Unix(42, 0)))
+
+	// Integers are kept.
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"cpu_usage": 42.5, "count": int64(3), "up": true},
+			time.Unix(42, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestApplyTimeout(t *testing.T) {
+	e := newExec(t, "influx", "sleep", "10")
+	e.Timeout.Duration = 100 * time.Millisecond
+	defer e.stop()
+
+	input := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"usage": 42.5},
+			time.Unix(42, 0)),
+		testutil.MustMetric("mem",
+			map[string]string{},
+			map[string]interface{}{"used": 42.5},
+			time.Unix(42, 0)),
+	}
+
+	// Metrics are passed on unchanged when the command does not answer.
+	actual := e.Apply(copyMetrics(input)...)
+	testutil.RequireMetricsEqual(t, input, actual)
+
+	// The command is not restarted right away.
+	actual = e.Apply(copyMetrics(input)...)
+	testutil.RequireMetricsEqual(t, input, actual)
+	require.Nil(t, e.cmd)
+}
+
+func TestApplyDropOnError(t *testing.T) {
+	e := newExec(t, "influx", "sleep", "10")
+	e.Timeout.Duration = 100 * time.Millisecond
+	e.DropOnError = true
+	defer e.stop()
+
+	input := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"usage": 42.5},
+			time.Unix(42, 0)),
+		testutil.MustMetric("mem",
+			map[string]string{},
+			map[string]interface{}{"used": 42.5},
+			time.Unix(42, 0)),
+	}
+
+	actual := e.Apply(copyMetrics(input)...)
+	require.Empty(t, actual)
+
+	// Also while waiting to restart the command.
+	actual = e.Apply(copyMetrics(input)...)
+	require.Empty(t, actual)
+}
+
+func TestApplyInvalidAnswerDropOnError(t *testing.T) {
+	e := newExec(t, "influx", "sh", "-c", `while read -r line; do echo "not a metric"; done`)
+	e.DropOnError = true
+	defer e.stop()
+
+	actual := e.Apply(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"usage": 42.5},
+		time.Unix(42, 0)))
+	require.Empty(t, actual)
+	require.NotNil(t, e.cmd)
+}
+
+func TestParseJSONNumbers(t *testing.T) {
+	m, err := parseJSON([]byte(`{"name":"cpu","fields":{"a":3,"b":2.5,"c":1e3,"d":18446744073709551615},"timestamp":42}`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"a": int64(3),
+		"b": 2.5,
+		"c": float64(1000),
+		"d": float64(18446744073709551615),
+	}, m.Fields())
+	require.Equal(t, time.Unix(0, 42), m.Time())
+}
+
+func TestApplyCommandExited(t *testing.T) {
+	e := newExec(t, "influx", "true")
+	defer e.stop()
+
+	input := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"usage": 42.5},
+		time.Unix(42, 0))
+
+	actual := e.Apply(input.Copy())
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{input}, actual)
+}
+
+func TestClose(t *testing.T) {
+	e := newExec(t, "influx", "cat")
+
+	input := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"usage": 42.5},
+		time.Unix(42, 0))
+	e.Apply(input.Copy())
+	cmd := e.cmd
+	require.NotNil(t, cmd)
+
+	require.NoError(t, e.Close())
+	require.Nil(t, e.cmd)
+	require.True(t, cmd.ProcessState.Exited())
+}
+
+func TestCloseKillsCommand(t *testing.T) {
+	// Keeps running after its stdin is closed.
+	e := newExec(t, "influx", "sh", "-c", "cat; exec sleep 10")
+	e.Timeout.Duration = 100 * time.Millisecond
+
+	input := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"usage": 42.5},
+		time.Unix(42, 0))
+	e.Apply(input.Copy())
+	cmd := e.cmd
+	require.NotNil(t, cmd)
+
+	done := e.done
+	require.NoError(t, e.Close())
+	<-done
+	require.False(t, cmd.ProcessState.Success())
+}
+
+func TestInitErrors(t *testing.T) {
+	e := &Exec{
+		DataFormat: "influx",
+		Timeout:    internal.Duration{Duration: time.Second},
+	}
+	require.Error(t, e.Init())
+
+	e.Command = []string{"cat"}
+	e.DataFormat = "xml"
+	require.Error(t, e.Init())
+
+	e.DataFormat = "influx"
+	e.Timeout.Duration = 0
+	require.Error(t, e.Init())
+}
+
+func copyMetrics(metrics []telegraf.Metric) []telegraf.Metric {
+	out := make([]telegraf.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		out = append(out, m.Copy())
+	}
+	return out
+}
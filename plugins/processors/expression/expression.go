@@ -0,0 +1,66 @@
+package expression
+
+import (
+	"fmt"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/expression"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Fields to add, computed from the other fields of the metric.  The
+  ## expressions can use the operators + - * / % and parentheses.  Fields
+  ## are only added if all fields in the expression are present and numeric.
+  [processors.expression.fields]
+    # used_percent = "used / total * 100"
+`
+
+type Expression struct {
+	Fields map[string]string `toml:"fields"`
+
+	exprs map[string]*expression.Arithmetic
+}
+
+func (e *Expression) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Expression) Description() string {
+	return "Add fields computed from arithmetic expressions over the fields."
+}
+
+func (e *Expression) Init() error {
+	e.exprs = make(map[string]*expression.Arithmetic, len(e.Fields))
+	for key, expr := range e.Fields {
+		a, err := expression.CompileArithmetic(expr)
+		if err != nil {
+			return fmt.Errorf("parsing expression of field %q: %v", key, err)
+		}
+		e.exprs[key] = a
+	}
+	return nil
+}
+
+func (e *Expression) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		// Evaluate all expressions before adding any fields, so that they
+		// only use the original fields.
+		values := make(map[string]float64, len(e.exprs))
+		for key, a := range e.exprs {
+			if v, ok := a.Eval(m); ok {
+				values[key] = v
+			}
+		}
+		for key, v := range values {
+			m.AddField(key, v)
+		}
+	}
+	return in
+}
+
+func init() {
+	processors.Add("expression", func() telegraf.Processor {
+		return &Expression{}
+	})
+}
@@ -0,0 +1,56 @@
+package expression
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	e := &Expression{
+		Fields: map[string]string{
+			"used_percent": "used / total * 100",
+			"free":         "total - used",
+			"missing":      "used / nonexistent",
+			"string":       "used + status",
+			"div_zero":     "used / (total - total)",
+		},
+	}
+	require.NoError(t, e.Init())
+
+	m := testutil.MustMetric("mem",
+		map[string]string{},
+		map[string]interface{}{
+			"used":   int64(25),
+			"total":  uint64(200),
+			"status": "ok",
+		},
+		time.Unix(0, 0),
+	)
+	expected := []telegraf.Metric{
+		testutil.MustMetric("mem",
+			map[string]string{},
+			map[string]interface{}{
+				"used":         int64(25),
+				"total":        uint64(200),
+				"status":       "ok",
+				"used_percent": float64(12.5),
+				"free":         float64(175),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, e.Apply(m))
+}
+
+func TestInitError(t *testing.T) {
+	e := &Expression{
+		Fields: map[string]string{
+			"bad": "used /",
+		},
+	}
+	require.Error(t, e.Init())
+}
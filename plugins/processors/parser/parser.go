@@ -74,6 +74,7 @@ func (p *Parser) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
 							if m.Name() == "" {
 								m.SetName(metric.Name())
 							}
+							m.SetRoute(metric.Route())
 						}
 
 						// multiple parsed fields shouldn't create multiple
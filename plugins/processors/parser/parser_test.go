@@ -668,3 +668,27 @@ func BenchmarkFields(b *testing.B) {
 		getMetricFields(metric)
 	}
 }
+
+func TestApplyKeepsRoute(t *testing.T) {
+	parser := Parser{
+		Config: parsers.Config{
+			DataFormat: "influx",
+		},
+		ParseFields:  []string{"message"},
+		DropOriginal: true,
+	}
+
+	m := Metric(metric.New(
+		"syslog",
+		map[string]string{},
+		map[string]interface{}{
+			"message": "cpu value=42",
+		},
+		time.Unix(0, 0)))
+	m.SetRoute("scripts")
+
+	output := parser.Apply(m)
+	require.Len(t, output, 1)
+	require.Equal(t, "cpu", output[0].Name())
+	require.Equal(t, "scripts", output[0].Route())
+}
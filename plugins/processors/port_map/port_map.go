@@ -0,0 +1,133 @@
+package portmap
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Tag containing the port number.
+  # tag = "port"
+
+  ## CSV file with the tags to add for each port.  The first row is a header
+  ## naming the port column followed by the tags, for example:
+  ##   port,service,team
+  ##   8080,web,frontend
+  # file = "/etc/telegraf/ports.csv"
+
+  ## Tags to add for each port, entries override those from the file.
+  # [processors.port_map.ports.8080]
+  #   service = "web"
+  #   team = "frontend"
+`
+
+type PortMap struct {
+	Tag   string                       `toml:"tag"`
+	File  string                       `toml:"file"`
+	Ports map[string]map[string]string `toml:"ports"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	mapping map[string]map[string]string
+}
+
+func (p *PortMap) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *PortMap) Description() string {
+	return "Add tags to metrics based on the value of their port tag."
+}
+
+func (p *PortMap) Init() error {
+	p.mapping = make(map[string]map[string]string)
+
+	if p.File != "" {
+		if err := p.loadFile(); err != nil {
+			return fmt.Errorf("loading %q: %v", p.File, err)
+		}
+	}
+
+	for port, tags := range p.Ports {
+		if p.mapping[port] == nil {
+			p.mapping[port] = make(map[string]string, len(tags))
+		}
+		for k, v := range tags {
+			p.mapping[port][k] = v
+		}
+	}
+	return nil
+}
+
+func (p *PortMap) loadFile() error {
+	file, err := os.Open(p.File)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	header := records[0]
+	if len(header) < 2 {
+		return fmt.Errorf("header must name the port column and at least one tag")
+	}
+
+	for _, record := range records[1:] {
+		port := strings.TrimSpace(record[0])
+		if port == "" {
+			continue
+		}
+		if _, ok := p.mapping[port]; ok {
+			p.Log.Warnf("Duplicate entry for port %q in %q", port, p.File)
+		}
+
+		tags := make(map[string]string, len(header)-1)
+		for i, key := range header[1:] {
+			if value := strings.TrimSpace(record[i+1]); value != "" {
+				tags[strings.TrimSpace(key)] = value
+			}
+		}
+		p.mapping[port] = tags
+	}
+	return nil
+}
+
+func (p *PortMap) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, metric := range in {
+		port, ok := metric.GetTag(p.Tag)
+		if !ok {
+			continue
+		}
+
+		// Existing tags are kept, the mapping only fills in missing tags.
+		for k, v := range p.mapping[port] {
+			if !metric.HasTag(k) {
+				metric.AddTag(k, v)
+			}
+		}
+	}
+	return in
+}
+
+func init() {
+	processors.Add("port_map", func() telegraf.Processor {
+		return &PortMap{
+			Tag: "port",
+		}
+	})
+}
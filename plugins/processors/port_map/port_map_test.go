@@ -0,0 +1,122 @@
+package portmap
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/influxdata/toml"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(tags map[string]string) telegraf.Metric {
+	return testutil.MustMetric(
+		"exec",
+		tags,
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	)
+}
+
+func TestApplyStatic(t *testing.T) {
+	p := &PortMap{
+		Tag: "port",
+		Ports: map[string]map[string]string{
+			"8080": {"service": "web", "team": "frontend"},
+			"5432": {"service": "postgres"},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, p.Init())
+
+	actual := p.Apply(
+		newMetric(map[string]string{"port": "8080"}),
+		newMetric(map[string]string{"port": "5432", "service": "pg-primary"}),
+		newMetric(map[string]string{"port": "9999"}),
+		newMetric(map[string]string{"host": "a"}),
+	)
+
+	expected := []telegraf.Metric{
+		newMetric(map[string]string{"port": "8080", "service": "web", "team": "frontend"}),
+		newMetric(map[string]string{"port": "5432", "service": "pg-primary"}),
+		newMetric(map[string]string{"port": "9999"}),
+		newMetric(map[string]string{"host": "a"}),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestApplyFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "port_map")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(`# port mapping
+port,service,team
+8080,web,frontend
+5432,postgres,
+`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	p := &PortMap{
+		Tag:  "listen_port",
+		File: file.Name(),
+		Ports: map[string]map[string]string{
+			"8080": {"team": "platform", "environment": "production"},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, p.Init())
+
+	actual := p.Apply(
+		newMetric(map[string]string{"listen_port": "8080"}),
+		newMetric(map[string]string{"listen_port": "5432"}),
+	)
+
+	expected := []telegraf.Metric{
+		newMetric(map[string]string{
+			"listen_port": "8080",
+			"service":     "web",
+			"team":        "platform",
+			"environment": "production",
+		}),
+		newMetric(map[string]string{"listen_port": "5432", "service": "postgres"}),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestInitErrors(t *testing.T) {
+	p := &PortMap{
+		Tag:  "port",
+		File: "/nonexistent/ports.csv",
+		Log:  testutil.Logger{},
+	}
+	require.Error(t, p.Init())
+
+	file, err := ioutil.TempFile("", "port_map")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString("port\n8080\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	p.File = file.Name()
+	require.Error(t, p.Init())
+}
+
+func TestConfigPorts(t *testing.T) {
+	p := &PortMap{}
+	err := toml.Unmarshal([]byte(`
+tag = "port"
+[ports.8080]
+  service = "web"
+  team = "frontend"
+`), p)
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]string{
+		"8080": {"service": "web", "team": "frontend"},
+	}, p.Ports)
+}
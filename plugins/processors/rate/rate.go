@@ -0,0 +1,156 @@
+package rate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Counter fields to compute the rate of, may contain globs.
+  fields = ["bytes_total"]
+
+  ## Whether to add the rate per second ("rate") or the difference ("delta")
+  ## since the previous metric of the series.
+  # output = "rate"
+
+  ## Suffix of the added fields, by default "_rate" or "_delta".
+  # suffix = ""
+
+  ## Series not seen for this long are forgotten, the next metric of the
+  ## series starts over without a rate.
+  # series_timeout = "1h"
+`
+
+type Rate struct {
+	Fields        []string          `toml:"fields"`
+	Output        string            `toml:"output"`
+	Suffix        string            `toml:"suffix"`
+	SeriesTimeout internal.Duration `toml:"series_timeout"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	fieldFilter filter.Filter
+	series      map[uint64]*series
+	lastCleanup time.Time
+}
+
+// series holds the previous values of the counters of a series.
+type series struct {
+	time     time.Time
+	lastSeen time.Time
+	values   map[string]float64
+}
+
+func (r *Rate) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Rate) Description() string {
+	return "Add the rate or difference of counter fields since the previous metric."
+}
+
+func (r *Rate) Init() error {
+	switch r.Output {
+	case "", "rate":
+		r.Output = "rate"
+		if r.Suffix == "" {
+			r.Suffix = "_rate"
+		}
+	case "delta":
+		if r.Suffix == "" {
+			r.Suffix = "_delta"
+		}
+	default:
+		return fmt.Errorf("invalid output %q, must be \"rate\" or \"delta\"", r.Output)
+	}
+
+	var err error
+	r.fieldFilter, err = filter.Compile(r.Fields)
+	if err != nil {
+		return err
+	}
+	if r.fieldFilter == nil {
+		return fmt.Errorf("no fields given")
+	}
+
+	r.series = make(map[uint64]*series)
+	r.lastCleanup = time.Now()
+	return nil
+}
+
+func (r *Rate) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	now := time.Now()
+	for _, m := range in {
+		r.apply(m, now)
+	}
+	r.cleanup(now)
+	return in
+}
+
+func (r *Rate) apply(m telegraf.Metric, now time.Time) {
+	id := m.HashID()
+	s, ok := r.series[id]
+	if !ok {
+		s = &series{values: make(map[string]float64)}
+		r.series[id] = s
+	}
+	elapsed := m.Time().Sub(s.time).Seconds()
+
+	for _, field := range m.FieldList() {
+		if !r.fieldFilter.Match(field.Key) {
+			continue
+		}
+		value, ok := internal.ToFloat64(field.Value)
+		if !ok {
+			continue
+		}
+
+		previous, ok := s.values[field.Key]
+		s.values[field.Key] = value
+		if !ok {
+			continue
+		}
+		if value < previous {
+			r.Log.Debugf("Counter %q of %q was reset", field.Key, m.Name())
+			continue
+		}
+
+		switch r.Output {
+		case "rate":
+			if elapsed <= 0 {
+				continue
+			}
+			m.AddField(field.Key+r.Suffix, (value-previous)/elapsed)
+		case "delta":
+			m.AddField(field.Key+r.Suffix, value-previous)
+		}
+	}
+	s.time = m.Time()
+	s.lastSeen = now
+}
+
+// cleanup forgets the series that timed out, at most once per timeout.
+func (r *Rate) cleanup(now time.Time) {
+	if r.SeriesTimeout.Duration <= 0 || now.Sub(r.lastCleanup) < r.SeriesTimeout.Duration {
+		return
+	}
+	r.lastCleanup = now
+	for id, s := range r.series {
+		if now.Sub(s.lastSeen) >= r.SeriesTimeout.Duration {
+			delete(r.series, id)
+		}
+	}
+}
+
+func init() {
+	processors.Add("rate", func() telegraf.Processor {
+		return &Rate{
+			SeriesTimeout: internal.Duration{Duration: time.Hour},
+		}
+	})
+}
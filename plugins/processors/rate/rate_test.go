@@ -0,0 +1,100 @@
+package rate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func counter(host string, fields map[string]interface{}, sec int64) telegraf.Metric {
+	return testutil.MustMetric("net",
+		map[string]string{
+			"host": host,
+		},
+		fields,
+		time.Unix(sec, 0),
+	)
+}
+
+func TestRate(t *testing.T) {
+	r := &Rate{
+		Fields: []string{"bytes_*"},
+		Log:    testutil.Logger{},
+	}
+	require.NoError(t, r.Init())
+
+	actual := r.Apply(
+		counter("a", map[string]interface{}{"bytes_total": int64(100), "errors": int64(1)}, 0),
+		counter("b", map[string]interface{}{"bytes_total": uint64(10)}, 0),
+	)
+	actual = append(actual, r.Apply(
+		counter("a", map[string]interface{}{"bytes_total": int64(300), "errors": int64(2)}, 10),
+		counter("b", map[string]interface{}{"bytes_total": uint64(20)}, 5),
+	)...)
+	// The counter of a is reset.
+	actual = append(actual, r.Apply(
+		counter("a", map[string]interface{}{"bytes_total": int64(50)}, 20),
+	)...)
+	actual = append(actual, r.Apply(
+		counter("a", map[string]interface{}{"bytes_total": float64(150)}, 30),
+	)...)
+
+	expected := []telegraf.Metric{
+		counter("a", map[string]interface{}{"bytes_total": int64(100), "errors": int64(1)}, 0),
+		counter("b", map[string]interface{}{"bytes_total": uint64(10)}, 0),
+		counter("a", map[string]interface{}{"bytes_total": int64(300), "errors": int64(2), "bytes_total_rate": float64(20)}, 10),
+		counter("b", map[string]interface{}{"bytes_total": uint64(20), "bytes_total_rate": float64(2)}, 5),
+		counter("a", map[string]interface{}{"bytes_total": int64(50)}, 20),
+		counter("a", map[string]interface{}{"bytes_total": float64(150), "bytes_total_rate": float64(10)}, 30),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestDelta(t *testing.T) {
+	r := &Rate{
+		Fields: []string{"bytes_total"},
+		Output: "delta",
+		Log:    testutil.Logger{},
+	}
+	require.NoError(t, r.Init())
+
+	actual := r.Apply(counter("a", map[string]interface{}{"bytes_total": int64(100)}, 0))
+	// The delta does not need the time to change.
+	actual = append(actual, r.Apply(counter("a", map[string]interface{}{"bytes_total": int64(150)}, 0))...)
+
+	expected := []telegraf.Metric{
+		counter("a", map[string]interface{}{"bytes_total": int64(100)}, 0),
+		counter("a", map[string]interface{}{"bytes_total": int64(150), "bytes_total_delta": float64(50)}, 0),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestSeriesTimeout(t *testing.T) {
+	r := &Rate{
+		Fields: []string{"bytes_total"},
+		Log:    testutil.Logger{},
+	}
+	r.SeriesTimeout.Duration = time.Minute
+	require.NoError(t, r.Init())
+
+	r.Apply(counter("a", map[string]interface{}{"bytes_total": int64(100)}, 0))
+	require.Len(t, r.series, 1)
+
+	r.lastCleanup = time.Now().Add(-time.Hour)
+	for _, s := range r.series {
+		s.lastSeen = time.Now().Add(-time.Hour)
+	}
+	r.Apply()
+	require.Len(t, r.series, 0)
+}
+
+func TestInitErrors(t *testing.T) {
+	r := &Rate{Fields: []string{"a"}, Output: "ratio"}
+	require.Error(t, r.Init())
+
+	r = &Rate{}
+	require.Error(t, r.Init())
+}
@@ -0,0 +1,150 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Fields every metric must have.
+  # required_fields = []
+
+  ## Tag keys the metrics may have, may contain globs.  By default all tags
+  ## are allowed.
+  # allowed_tags = ["*"]
+
+  ## What to do with the metrics failing validation, either "drop" them or
+  ## replace them with an "error" metric reporting the reason.
+  # action = "drop"
+
+  ## Measurement of the error metrics.
+  # error_measurement = "schema_error"
+
+  ## Types of the fields, one of "float", "integer", "unsigned", "string" or
+  ## "boolean".  Fields not listed may have any type.
+  # [processors.schema.field_types]
+  #   value = "float"
+`
+
+type Schema struct {
+	RequiredFields   []string          `toml:"required_fields"`
+	FieldTypes       map[string]string `toml:"field_types"`
+	AllowedTags      []string          `toml:"allowed_tags"`
+	Action           string            `toml:"action"`
+	ErrorMeasurement string            `toml:"error_measurement"`
+
+	tagFilter filter.Filter
+}
+
+func (s *Schema) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Schema) Description() string {
+	return "Drop or report metrics not matching the expected fields, field types and tags."
+}
+
+func (s *Schema) Init() error {
+	switch s.Action {
+	case "":
+		s.Action = "drop"
+	case "drop", "error":
+	default:
+		return fmt.Errorf("invalid action %q, must be \"drop\" or \"error\"", s.Action)
+	}
+
+	for key, typ := range s.FieldTypes {
+		switch typ {
+		case "float", "integer", "unsigned", "string", "boolean":
+		default:
+			return fmt.Errorf("invalid type %q of field %q", typ, key)
+		}
+	}
+
+	var err error
+	s.tagFilter, err = filter.Compile(s.AllowedTags)
+	return err
+}
+
+func (s *Schema) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := in[:0]
+	for _, m := range in {
+		reason := s.validate(m)
+		if reason == "" {
+			out = append(out, m)
+			continue
+		}
+
+		if s.Action == "error" {
+			out = append(out, s.errorMetric(m, reason))
+		}
+		m.Drop()
+	}
+	return out
+}
+
+// validate returns the reason the metric is invalid, or an empty string.
+func (s *Schema) validate(m telegraf.Metric) string {
+	for _, key := range s.RequiredFields {
+		if !m.HasField(key) {
+			return fmt.Sprintf("missing field %q", key)
+		}
+	}
+
+	for _, field := range m.FieldList() {
+		expected, ok := s.FieldTypes[field.Key]
+		if !ok {
+			continue
+		}
+		if typ := fieldType(field.Value); typ != expected {
+			return fmt.Sprintf("field %q is %s, expected %s", field.Key, typ, expected)
+		}
+	}
+
+	if s.tagFilter != nil {
+		for _, tag := range m.TagList() {
+			if !s.tagFilter.Match(tag.Key) {
+				return fmt.Sprintf("tag %q not allowed", tag.Key)
+			}
+		}
+	}
+	return ""
+}
+
+// errorMetric returns the metric reporting why the metric is invalid.
+func (s *Schema) errorMetric(m telegraf.Metric, reason string) telegraf.Metric {
+	e, _ := metric.New(s.ErrorMeasurement,
+		map[string]string{"measurement": m.Name()},
+		map[string]interface{}{"reason": reason},
+		m.Time())
+	e.SetRoute(m.Route())
+	return e
+}
+
+func fieldType(v interface{}) string {
+	switch v.(type) {
+	case float64:
+		return "float"
+	case int64:
+		return "integer"
+	case uint64:
+		return "unsigned"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func init() {
+	processors.Add("schema", func() telegraf.Processor {
+		return &Schema{
+			ErrorMeasurement: "schema_error",
+		}
+	})
+}
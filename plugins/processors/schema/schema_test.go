@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		schema   *Schema
+		input    telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "valid",
+			schema: &Schema{
+				RequiredFields: []string{"value"},
+				FieldTypes:     map[string]string{"value": "float"},
+				AllowedTags:    []string{"host"},
+			},
+			input: testutil.MustMetric("cpu",
+				map[string]string{"host": "a"},
+				map[string]interface{}{"value": 42.0},
+				now),
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 42.0},
+					now),
+			},
+		},
+		{
+			name: "missing field",
+			schema: &Schema{
+				RequiredFields: []string{"value"},
+			},
+			input: testutil.MustMetric("cpu",
+				map[string]string{},
+				map[string]interface{}{"other": 42.0},
+				now),
+		},
+		{
+			name: "wrong type",
+			schema: &Schema{
+				FieldTypes: map[string]string{"value": "float"},
+			},
+			input: testutil.MustMetric("cpu",
+				map[string]string{},
+				map[string]interface{}{"value": "garbage"},
+				now),
+		},
+		{
+			name: "tag not allowed",
+			schema: &Schema{
+				AllowedTags: []string{"host"},
+			},
+			input: testutil.MustMetric("cpu",
+				map[string]string{"host": "a", "request_id": "1234"},
+				map[string]interface{}{"value": 42.0},
+				now),
+		},
+		{
+			name: "error metric",
+			schema: &Schema{
+				FieldTypes:       map[string]string{"value": "float"},
+				Action:           "error",
+				ErrorMeasurement: "schema_error",
+			},
+			input: testutil.MustMetric("cpu",
+				map[string]string{},
+				map[string]interface{}{"value": int64(42)},
+				now),
+			expected: []telegraf.Metric{
+				testutil.MustMetric("schema_error",
+					map[string]string{"measurement": "cpu"},
+					map[string]interface{}{"reason": `field "value" is integer, expected float`},
+					now),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.schema.Init())
+			actual := tt.schema.Apply(tt.input)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestErrorMetricKeepsRoute(t *testing.T) {
+	s := &Schema{
+		RequiredFields:   []string{"value"},
+		Action:           "error",
+		ErrorMeasurement: "schema_error",
+	}
+	require.NoError(t, s.Init())
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"other": 42.0},
+		time.Now())
+	m.SetRoute("collector")
+	actual := s.Apply(m)
+	require.Len(t, actual, 1)
+	require.Equal(t, "collector", actual[0].Route())
+}
+
+func TestInitInvalid(t *testing.T) {
+	require.Error(t, (&Schema{Action: "route"}).Init())
+	require.Error(t, (&Schema{FieldTypes: map[string]string{"value": "double"}}).Init())
+}
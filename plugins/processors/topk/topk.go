@@ -322,6 +322,7 @@ func (t *TopK) push() []telegraf.Metric {
 		if err != nil {
 			continue
 		}
+		copy.SetRoute(m.Route())
 		result = append(result, copy)
 	}
 
@@ -7,6 +7,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
 )
 
 // Key, value pair that represents a telegraf.Metric Field
@@ -530,3 +531,19 @@ func TestTopkGroupByKeyTag(t *testing.T) {
 	// Run the test
 	runAndCompare(&topk, input, answer, "GroupByKeyTag test", t)
 }
+
+func TestTopkKeepsRoute(t *testing.T) {
+	topk := New()
+	topk.Period = internal.Duration{Duration: time.Millisecond}
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{"host": "a"},
+		map[string]interface{}{"value": 42.0},
+		time.Now())
+	m.SetRoute("scripts")
+
+	time.Sleep(topk.Period.Duration)
+	ret := topk.Apply(m)
+	require.Len(t, ret, 1)
+	require.Equal(t, "scripts", ret[0].Route())
+}
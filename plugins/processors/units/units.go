@@ -0,0 +1,143 @@
+package units
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Conversions to apply, the fields may contain globs.  A field is
+  ## converted by the first conversion matching it.  Converted fields are
+  ## always floats, integer and unsigned fields are changed to floats.
+  [[processors.units.conversion]]
+    fields = ["mem_*"]
+    from = "KiB"
+    to = "bytes"
+
+  # [[processors.units.conversion]]
+  #   fields = ["latency"]
+  #   from = "ms"
+  #   to = "s"
+`
+
+type unit struct {
+	quantity string
+	// factor to convert to the base unit of the quantity
+	factor float64
+}
+
+var units = map[string]unit{
+	"bits":  {"data", 1.0 / 8},
+	"bytes": {"data", 1},
+	"B":     {"data", 1},
+	"KB":    {"data", 1e3},
+	"MB":    {"data", 1e6},
+	"GB":    {"data", 1e9},
+	"TB":    {"data", 1e12},
+	"KiB":   {"data", 1 << 10},
+	"MiB":   {"data", 1 << 20},
+	"GiB":   {"data", 1 << 30},
+	"TiB":   {"data", 1 << 40},
+
+	"ns":  {"time", 1e-9},
+	"us":  {"time", 1e-6},
+	"ms":  {"time", 1e-3},
+	"s":   {"time", 1},
+	"min": {"time", 60},
+	"h":   {"time", 3600},
+
+	"percent": {"ratio", 0.01},
+	"ratio":   {"ratio", 1},
+}
+
+type Conversion struct {
+	Fields []string `toml:"fields"`
+	From   string   `toml:"from"`
+	To     string   `toml:"to"`
+
+	filter filter.Filter
+	factor float64
+}
+
+type Units struct {
+	Conversions []*Conversion `toml:"conversion"`
+
+	Log telegraf.Logger `toml:"-"`
+}
+
+func (u *Units) SampleConfig() string {
+	return sampleConfig
+}
+
+func (u *Units) Description() string {
+	return "Convert field values between units."
+}
+
+func (u *Units) Init() error {
+	for _, c := range u.Conversions {
+		from, ok := units[c.From]
+		if !ok {
+			return fmt.Errorf("unknown unit %q, must be one of %s", c.From, unitNames())
+		}
+		to, ok := units[c.To]
+		if !ok {
+			return fmt.Errorf("unknown unit %q, must be one of %s", c.To, unitNames())
+		}
+		if from.quantity != to.quantity {
+			return fmt.Errorf("can not convert %s to %s", c.From, c.To)
+		}
+		c.factor = from.factor / to.factor
+
+		var err error
+		c.filter, err = filter.Compile(c.Fields)
+		if err != nil {
+			return err
+		}
+		if c.filter == nil {
+			return fmt.Errorf("no fields given to convert from %s to %s", c.From, c.To)
+		}
+	}
+	return nil
+}
+
+func unitNames() string {
+	names := make([]string, 0, len(units))
+	for name := range units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func (u *Units) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		for _, field := range m.FieldList() {
+			for _, c := range u.Conversions {
+				if !c.filter.Match(field.Key) {
+					continue
+				}
+
+				value, ok := internal.ToFloat64(field.Value)
+				if !ok {
+					u.Log.Debugf("Field %q of %q is not numeric, not converting it", field.Key, m.Name())
+					break
+				}
+				m.AddField(field.Key, value*c.factor)
+				break
+			}
+		}
+	}
+	return in
+}
+
+func init() {
+	processors.Add("units", func() telegraf.Processor {
+		return &Units{}
+	})
+}
@@ -0,0 +1,78 @@
+package units
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	u := &Units{
+		Conversions: []*Conversion{
+			{Fields: []string{"mem_*"}, From: "KiB", To: "bytes"},
+			{Fields: []string{"latency"}, From: "ms", To: "s"},
+			{Fields: []string{"usage"}, From: "percent", To: "ratio"},
+			// Not used, mem_free is converted by the first conversion.
+			{Fields: []string{"mem_free"}, From: "KiB", To: "MiB"},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, u.Init())
+
+	m := testutil.MustMetric("script",
+		map[string]string{},
+		map[string]interface{}{
+			"mem_total": int64(2048),
+			"mem_free":  uint64(1024),
+			"latency":   float64(250),
+			"usage":     int64(50),
+			"status":    "ok",
+			"count":     int64(3),
+		},
+		time.Unix(0, 0),
+	)
+	expected := []telegraf.Metric{
+		testutil.MustMetric("script",
+			map[string]string{},
+			map[string]interface{}{
+				"mem_total": float64(2097152),
+				"mem_free":  float64(1048576),
+				"latency":   float64(0.25),
+				"usage":     float64(0.5),
+				"status":    "ok",
+				"count":     int64(3),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, u.Apply(m))
+}
+
+func TestInitErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		conversion *Conversion
+	}{
+		{
+			name:       "unknown unit",
+			conversion: &Conversion{Fields: []string{"a"}, From: "furlong", To: "s"},
+		},
+		{
+			name:       "different quantities",
+			conversion: &Conversion{Fields: []string{"a"}, From: "ms", To: "bytes"},
+		},
+		{
+			name:       "no fields",
+			conversion: &Conversion{From: "ms", To: "s"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &Units{Conversions: []*Conversion{tt.conversion}}
+			require.Error(t, u.Init())
+		})
+	}
+}
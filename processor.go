@@ -10,3 +10,10 @@ type Processor interface {
 	// Apply the filter to the given metric.
 	Apply(in ...Metric) []Metric
 }
+
+// ProcessorCloser is implemented by processors holding resources, such as a
+// running command, that must be released when the agent stops.
+type ProcessorCloser interface {
+	// Close is called once after the last metric was applied.
+	Close() error
+}